@@ -0,0 +1,59 @@
+package core
+
+import (
+	"crypto/subtle"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+// tokenRetention is how long the tokens of a finished game stay valid, as long as its events can be read
+const tokenRetention = 10 * time.Minute
+
+// ErrUnauthorized is the cause of the errors returned when the token of a player is not correct
+var ErrUnauthorized = errors.New("player token is not correct")
+
+var (
+	tokensLock sync.Mutex
+	// playerTokens has the token of every player, by player id
+	playerTokens = make(map[uuid.UUID]playerToken)
+)
+
+type playerToken struct {
+	gameID uuid.UUID
+	token  string
+}
+
+// Authenticate checks the token the caller gave for the player of the game
+func Authenticate(gameID, playerID uuid.UUID, token string) error {
+	tokensLock.Lock()
+	t, ok := playerTokens[playerID]
+	tokensLock.Unlock()
+	if !ok || t.gameID != gameID || subtle.ConstantTimeCompare([]byte(t.token), []byte(token)) != 1 {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+func registerToken(gameID uuid.UUID, p *Player) {
+	tokensLock.Lock()
+	defer tokensLock.Unlock()
+	playerTokens[p.ID] = playerToken{gameID: gameID, token: p.Token}
+}
+
+func forgetToken(playerID uuid.UUID) {
+	tokensLock.Lock()
+	defer tokensLock.Unlock()
+	delete(playerTokens, playerID)
+}
+
+// forgetTokensLater drops the tokens of the players of a finished game after tokenRetention
+func forgetTokensLater(g *game) {
+	playerIDs := append([]uuid.UUID(nil), g.playerOrder...)
+	time.AfterFunc(tokenRetention, func() {
+		for _, id := range playerIDs {
+			forgetToken(id)
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidAPIKey is the cause of the errors returned when an API key does not belong to any bot
+var ErrInvalidAPIKey = errors.New("api key is not correct")
+
+// assignedNamePattern matches the names given to anonymous players, bots cannot take them
+var assignedNamePattern = regexp.MustCompile(`^Player[0-9]+$`)
+
+// Bot is a registered bot, its id and name stay the same across games
+type Bot struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedAt time.Time
+}
+
+type botRecord struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	// APIKeyHash is the hex encoded SHA-256 of the API key, the key itself is only known by the bot
+	APIKeyHash string `json:"apiKeyHash"`
+}
+
+var (
+	botsLock sync.Mutex
+	bots     = make(map[uuid.UUID]*botRecord)
+	// botsFile is where the bots are saved, they are only kept in memory when it is empty
+	botsFile string
+)
+
+// LoadBots loads the bots registered previously from the given file, and saves every change to the bots there
+func LoadBots(path string) error {
+	botsLock.Lock()
+	defer botsLock.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "could not create bots directory")
+	}
+	botsFile = path
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not read bots")
+	}
+	var records []*botRecord
+	if err := json.Unmarshal(body, &records); err != nil {
+		return errors.Wrap(err, "could not decode bots")
+	}
+	for _, record := range records {
+		bots[record.ID] = record
+	}
+	logger.Infof("Loaded %d bots from %s", len(records), path)
+	return nil
+}
+
+// CreateBot registers a bot with the given name, which is reserved for it from then on,
+// the returned API key is not kept, only a hash of it
+func CreateBot(name string) (Bot, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Bot{}, "", errors.New("could not create bot: name cannot be empty")
+	}
+	if assignedNamePattern.MatchString(name) {
+		return Bot{}, "", errors.Errorf("could not create bot: names like %s are given to anonymous players", name)
+	}
+	botsLock.Lock()
+	defer botsLock.Unlock()
+	if _, ok := botByName(name); ok {
+		return Bot{}, "", errors.Errorf("could not create bot: name %s is already taken", name)
+	}
+	apiKey := newSecret()
+	record := &botRecord{ID: uuid.New(), Name: name, CreatedAt: time.Now().UTC(), APIKeyHash: hashAPIKey(apiKey)}
+	bots[record.ID] = record
+	if err := saveBots(); err != nil {
+		delete(bots, record.ID)
+		return Bot{}, "", errors.Wrap(err, "could not create bot")
+	}
+	return record.bot(), apiKey, nil
+}
+
+// RotateBotKey replaces the API key of the bot, the previous key stops working
+func RotateBotKey(botID uuid.UUID) (Bot, string, error) {
+	botsLock.Lock()
+	defer botsLock.Unlock()
+	record, ok := bots[botID]
+	if !ok {
+		return Bot{}, "", errors.New("could not rotate api key: bot id is not correct")
+	}
+	previousHash := record.APIKeyHash
+	apiKey := newSecret()
+	record.APIKeyHash = hashAPIKey(apiKey)
+	if err := saveBots(); err != nil {
+		record.APIKeyHash = previousHash
+		return Bot{}, "", errors.Wrap(err, "could not rotate api key")
+	}
+	return record.bot(), apiKey, nil
+}
+
+// Bots returns the registered bots, ordered by name
+func Bots() []Bot {
+	botsLock.Lock()
+	defer botsLock.Unlock()
+	result := make([]Bot, 0, len(bots))
+	for _, record := range bots {
+		result = append(result, record.bot())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// botNames returns the names of the registered bots, by id
+func botNames() map[uuid.UUID]string {
+	botsLock.Lock()
+	defer botsLock.Unlock()
+	names := make(map[uuid.UUID]string, len(bots))
+	for id, record := range bots {
+		names[id] = record.Name
+	}
+	return names
+}
+
+// authenticateBot returns the bot the API key belongs to
+func authenticateBot(apiKey string) (Bot, error) {
+	hash := hashAPIKey(apiKey)
+	botsLock.Lock()
+	defer botsLock.Unlock()
+	for _, record := range bots {
+		if subtle.ConstantTimeCompare([]byte(record.APIKeyHash), []byte(hash)) == 1 {
+			return record.bot(), nil
+		}
+	}
+	return Bot{}, ErrInvalidAPIKey
+}
+
+// identifyPlayer returns the bot the API key belongs to and its name, or the given name for an anonymous player,
+// which cannot be the name of a registered bot
+func identifyPlayer(apiKey, playerName string) (Bot, string, error) {
+	if apiKey != "" {
+		bot, err := authenticateBot(apiKey)
+		if err != nil {
+			return Bot{}, "", err
+		}
+		return bot, bot.Name, nil
+	}
+	if playerName != "" && isReservedName(playerName) {
+		return Bot{}, "", errors.Errorf("name %s is reserved for a registered bot, connect with its api key", playerName)
+	}
+	return Bot{}, playerName, nil
+}
+
+// isReservedName tells whether the name belongs to a registered bot, names are compared case insensitively
+func isReservedName(name string) bool {
+	botsLock.Lock()
+	defer botsLock.Unlock()
+	_, ok := botByName(name)
+	return ok
+}
+
+func botByName(name string) (*botRecord, bool) {
+	for _, record := range bots {
+		if strings.EqualFold(record.Name, name) {
+			return record, true
+		}
+	}
+	return nil, false
+}
+
+func (r *botRecord) bot() Bot {
+	return Bot{ID: r.ID, Name: r.Name, CreatedAt: r.CreatedAt}
+}
+
+func hashAPIKey(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// saveBots writes every bot to the bots file, it has to be called holding botsLock
+func saveBots() error {
+	if botsFile == "" {
+		return nil
+	}
+	records := make([]*botRecord, 0, len(bots))
+	for _, record := range bots {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	body, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "could not encode bots")
+	}
+	tmp := botsFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, body, 0600); err != nil {
+		return errors.Wrap(err, "could not write bots")
+	}
+	return errors.Wrap(os.Rename(tmp, botsFile), "could not write bots")
+}
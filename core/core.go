@@ -4,38 +4,49 @@ package core
 import (
 	"botServer/core/events"
 	"botServer/core/games"
+	"context"
 	"fmt"
 	"github.com/google/logger"
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 )
 
+// reconnectGracePeriod is how long a game waits for a player whose websocket was lost
+const reconnectGracePeriod = 30 * time.Second
+
 var (
-	tokenToGameID     = make(map[string]uuid.UUID)
-	tokenToGameIDLock sync.RWMutex
-	gameIDToGame      = make(map[uuid.UUID]*game)
-	gameIDToGameLock  sync.RWMutex
-	playerNameNr      int64
+	store        = NewMemoryStore()
+	playerNameNr int64
 )
 
-type game struct {
-	id              uuid.UUID
-	gameType        games.GameType
-	numberOfPlayers int
-	players         map[uuid.UUID]*Player
-	currentRound    int
-	totalRounds     int
+// SetGameStore replaces the store where games are kept, it should be called before serving any request
+func SetGameStore(s GameStore) {
+	store = s
+	for _, g := range store.List() {
+		waitingToStart := g.currentRound == 0 && len(g.players) == g.numberOfPlayers
+		if g.currentRound > 0 {
+			startRoundTimer(g)
+			// websocket players have to reconnect after a restart
+			for _, id := range g.playerOrder {
+				if p := g.players[id]; !isReachable(p) {
+					pauseGame(g, p)
+				}
+			}
+		}
+		g.start()
+		if waitingToStart {
+			g.sendLater(startCommand{attemptsLeft: startAttempts}, 0)
+		}
+	}
 }
 
 // StartCleaner starts the process that cleans up unfinished games
 func StartCleaner() {
 	ticker := time.NewTicker(5 * time.Minute)
-	previousRounds := make(map[string]int)
 
 	go func() {
 		for {
@@ -43,31 +54,17 @@ func StartCleaner() {
 			case t := <-ticker.C:
 				logger.Infof("Cleanup activates at %v", t)
 				var tokensToCleanup []string
-				tokenToGameIDLock.RLock()
-				gameIDToGameLock.RLock()
-				for token, gameID := range tokenToGameID {
-					game := gameIDToGame[gameID]
-					previousRound, ok := previousRounds[token]
-					if ok && game.currentRound == previousRound {
-						tokensToCleanup = append(tokensToCleanup, token)
+				for _, game := range store.List() {
+					cmd := cleanupCommand{reply: make(chan bool, 1)}
+					if err := game.send(cmd); err != nil {
+						continue
 					}
-					if !ok {
-						previousRounds[token] = game.currentRound
+					if <-cmd.reply {
+						tokensToCleanup = append(tokensToCleanup, game.token)
 					}
 				}
-				tokenToGameIDLock.RUnlock()
-				gameIDToGameLock.RUnlock()
 
 				if len(tokensToCleanup) > 0 {
-					tokenToGameIDLock.Lock()
-					gameIDToGameLock.Lock()
-					for _, token := range tokensToCleanup {
-						delete(gameIDToGame, tokenToGameID[token])
-						delete(tokenToGameID, token)
-						delete(previousRounds, token)
-					}
-					tokenToGameIDLock.Unlock()
-					gameIDToGameLock.Unlock()
 					logger.Infof("Cleaned up tokens: %s", strings.Join(tokensToCleanup, ", "))
 				} else {
 					logger.Infof("No tokens to clean up")
@@ -79,72 +76,240 @@ func StartCleaner() {
 
 // Connect tries to connect a new user to a game specified by the token
 func Connect(req ConnectRequest) (ConnectResponse, error) {
-	g, err := getOrCreateGame(req.Token, req.GameName, req.NoOfPlayers, req.TotalRounds)
+	bot, playerName, err := identifyPlayer(req.APIKey, req.PlayerName)
+	if err != nil {
+		return ConnectResponse{}, errors.Wrap(err, "could not connect to game")
+	}
+	req.PlayerName = playerName
+	g, err := getOrCreateGame(req)
 	if err != nil {
 		return ConnectResponse{}, errors.Wrap(err, "could not connect to game")
 	}
 	logger.Infof("Game with id %s was created", g.id.String())
-	p := getOrCreatePlayer(req.PlayerName, req.EventCallback)
-	if len(g.players) >= g.numberOfPlayers {
-		err := errors.New("all players are already connected")
+	player := getOrCreatePlayer(req.PlayerName, req.EventCallback)
+	player.Transport = req.Transport
+	player.LegacyScore = req.LegacyScore
+	player.Polling = req.Polling
+	player.BotID = bot.ID
+	if req.PlayerToken != "" {
+		player.Token = req.PlayerToken
+	}
+	if req.SigningSecret != "" {
+		player.SigningSecret = req.SigningSecret
+		player.callback = newCallbackTransport(player.EventCallback, player.SigningSecret)
+	}
+	cmd := connectCommand{
+		player: player,
+		reply:  make(chan connectReply, 1),
+	}
+	if err := g.send(cmd); err != nil {
 		return ConnectResponse{}, errors.Wrap(err, "could not connect to game")
 	}
-	g.players[p.ID] = p
-	logger.Infof("Player with id %s joined the game with id %s", p.ID.String(), g.id.String())
-	if len(g.players) == g.numberOfPlayers {
-		go tryStartGame(g)
+	reply := <-cmd.reply
+	if reply.err != nil {
+		return ConnectResponse{}, errors.Wrap(reply.err, "could not connect to game")
+	}
+	logger.Infof("Player with id %s joined the game with id %s", reply.response.Player.ID.String(), g.id.String())
+	return reply.response, nil
+}
+
+// RegisterTransport sets the connection through which the player will be notified, and resumes
+// the game if it was paused for the player, the events after lastSeq are sent again, none if lastSeq is negative
+func RegisterTransport(gameID, playerID uuid.UUID, token string, transport events.Transport, lastSeq int) error {
+	if err := Authenticate(gameID, playerID, token); err != nil {
+		return errors.Wrap(err, "could not register transport")
+	}
+	g, ok := store.Get(gameID)
+	if !ok {
+		err := errors.New("game id is not correct")
+		return errors.Wrap(err, "could not register transport")
+	}
+	cmd := registerTransportCommand{playerID: playerID, transport: transport, lastSeq: lastSeq, reply: make(chan error, 1)}
+	if err := g.send(cmd); err != nil {
+		return errors.Wrap(err, "could not register transport")
 	}
-	return ConnectResponse{GameID: g.id, Player: *p, Rounds: g.totalRounds}, nil
+	return errors.Wrap(<-cmd.reply, "could not register transport")
 }
 
-func RegisterWS(gameId, playerId uuid.UUID, conn *websocket.Conn) error {
-	gameIDToGameLock.RLock()
-	g, ok := gameIDToGame[gameId]
-	gameIDToGameLock.RUnlock()
+// DisconnectTransport tells the game that the connection of the player was lost, a started game is paused
+// until the player reconnects, or ends if the player does not reconnect in time,
+// unless the player still gets the events through its callback or by polling
+func DisconnectTransport(gameID, playerID uuid.UUID, transport events.Transport) error {
+	g, ok := store.Get(gameID)
 	if !ok {
 		err := errors.New("game id is not correct")
-		return errors.Wrap(err, "could not register ws")
+		return errors.Wrap(err, "could not disconnect transport")
+	}
+	cmd := disconnectTransportCommand{playerID: playerID, transport: transport, reply: make(chan error, 1)}
+	if err := g.send(cmd); err != nil {
+		return errors.Wrap(err, "could not disconnect transport")
 	}
-	p, ok := g.players[playerId]
+	return errors.Wrap(<-cmd.reply, "could not disconnect transport")
+}
+
+// Leave removes the player from the game, if the game already started it ends, and the player who left loses
+func Leave(gameID, playerID uuid.UUID, token string) error {
+	if err := Authenticate(gameID, playerID, token); err != nil {
+		return errors.Wrap(err, "could not leave game")
+	}
+	g, ok := store.Get(gameID)
 	if !ok {
+		err := errors.New("game id is not correct")
+		return errors.Wrap(err, "could not leave game")
+	}
+	cmd := leaveCommand{playerID: playerID, reply: make(chan error, 1)}
+	if err := g.send(cmd); err != nil {
+		return errors.Wrap(err, "could not leave game")
+	}
+	return errors.Wrap(<-cmd.reply, "could not leave game")
+}
+
+// Events returns the events sent to the player after the given sequence number, waiting at most wait
+// for one to be published, the events of a finished game can still be read for a while
+func Events(ctx context.Context, gameID, playerID uuid.UUID, token string, since int, wait time.Duration) ([]events.Event, error) {
+	if err := Authenticate(gameID, playerID, token); err != nil {
+		return nil, errors.Wrap(err, "could not get events")
+	}
+	log, ok := events.GetGameLog(gameID)
+	if !ok {
+		err := errors.New("game id is not correct")
+		return nil, errors.Wrap(err, "could not get events")
+	}
+	if !log.HasPlayer(playerID) {
 		err := errors.New("player id is not correct")
-		return errors.Wrap(err, "could not register ws")
+		return nil, errors.Wrap(err, "could not get events")
+	}
+	return log.Wait(ctx, playerID, since, wait), nil
+}
+
+// Spectate sends the startGame, roundFinished and gameFinished events of the game through the transport,
+// starting with the ones after lastSeq, the spectator is not a player of the game,
+// the events of a finished game can still be watched for a while
+func Spectate(gameID uuid.UUID, transport events.Transport, lastSeq int) error {
+	if g, ok := store.Get(gameID); ok {
+		cmd := spectateCommand{transport: transport, lastSeq: lastSeq, reply: make(chan error, 1)}
+		if err := g.send(cmd); err == nil {
+			return errors.Wrap(<-cmd.reply, "could not spectate game")
+		}
 	}
-	p.WebsocketConn = conn
+	log, ok := events.GetGameLog(gameID)
+	if !ok {
+		err := errors.New("game id is not correct")
+		return errors.Wrap(err, "could not spectate game")
+	}
+	events.Replay(transport, log.Since(events.SpectatorID, lastSeq))
 	return nil
 }
 
+// StopSpectating stops sending the events of the game through the transport
+func StopSpectating(gameID uuid.UUID, transport events.Transport) {
+	if g, ok := store.Get(gameID); ok {
+		_ = g.send(stopSpectatingCommand{transport: transport})
+	}
+}
+
 // Play processes a given player's move in a given round in a specific game
 func Play(req PlayRequest) (PlayResponse, error) {
-	gameIDToGameLock.RLock()
-	g, ok := gameIDToGame[req.GameID]
-	gameIDToGameLock.RUnlock()
+	if err := Authenticate(req.GameID, req.PlayerID, req.Token); err != nil {
+		return PlayResponse{}, errors.Wrap(err, "could not make move")
+	}
+	g, ok := store.Get(req.GameID)
 	if !ok {
 		err := errors.New("game id is not correct")
 		return PlayResponse{}, errors.Wrap(err, "could not make move")
 	}
-	if g.currentRound == 0 {
-		err := errors.New("game has not started yet")
+	cmd := moveCommand{request: req, reply: make(chan moveReply, 1)}
+	if err := g.send(cmd); err != nil {
 		return PlayResponse{}, errors.Wrap(err, "could not make move")
 	}
-	if req.Round != g.currentRound {
-		err := errors.Errorf("%d is not the current round (%d)", req.Round, g.currentRound)
-		return PlayResponse{}, errors.Wrap(err, "could not make move")
+	reply := <-cmd.reply
+	if reply.err != nil {
+		return PlayResponse{}, errors.Wrap(reply.err, "could not make move")
+	}
+	return reply.response, nil
+}
+
+func getOrCreateGame(req ConnectRequest) (*game, error) {
+	if req.Token == "" {
+		return nil, errors.New("token is empty")
 	}
-	err := g.gameType.ValidateMove(req.Move)
+	if g, ok := store.GetByToken(req.Token); ok {
+		return g, nil
+	}
+	gameType, err := games.NewGame(req.GameName)
 	if err != nil {
-		return PlayResponse{}, errors.Wrap(err, "could not make move")
+		return nil, errors.Wrap(err, "could not create new game")
+	}
+	numberOfPlayers, err := getNumberOfPlayers(gameType, req.NoOfPlayers)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create new game")
+	}
+	timeoutPolicy, err := getTimeoutPolicy(req.TimeoutPolicy)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create new game")
+	}
+	if req.MoveTimeout < 0 {
+		return nil, errors.New("could not create new game: move timeout cannot be negative")
+	}
+	options := games.Options(req.GameOptions)
+	if err := gameType.ValidateOptions(options); err != nil {
+		return nil, errors.Wrap(err, "could not create new game")
+	}
+
+	totalRounds := req.TotalRounds
+	if totalRounds <= 0 {
+		totalRounds = gameType.GetDefaultNumberOfRounds()
+	}
+	g := newGame(uuid.New(), req.Token, req.GameName, gameType, numberOfPlayers, totalRounds)
+	g.moveTimeout = req.MoveTimeout
+	g.timeoutPolicy = timeoutPolicy
+	g.options = options
+	added, err := store.Add(g)
+	if err != nil {
+		return nil, err
+	}
+	if added == g {
+		recordGameCreated(g)
+		g.start()
+	}
+	return added, nil
+}
+
+func play(g *game, req PlayRequest) (PlayResponse, error) {
+	if g.currentRound == 0 {
+		return PlayResponse{}, errors.New("game has not started yet")
+	}
+	if req.Round != g.currentRound {
+		return PlayResponse{}, errors.Errorf("%d is not the current round (%d)", req.Round, g.currentRound)
 	}
 	p, ok := g.players[req.PlayerID]
 	if !ok {
-		err := errors.New("player id is not correct")
-		return PlayResponse{}, errors.Wrap(err, "could not make move")
+		return PlayResponse{}, errors.New("player id is not correct")
+	}
+	if len(g.pausedBy) > 0 {
+		return PlayResponse{}, errors.Errorf("game is paused, waiting for %s to reconnect", strings.Join(pausingPlayerNames(g), ", "))
 	}
-	logger.Infof("Play for game %s round %d and player %s", req.GameID.String(), req.Round, g.players[req.PlayerID].Name)
+	if !hasToMove(g, p) {
+		var names []string
+		for _, player := range playersInTurn(g) {
+			names = append(names, player.Name)
+		}
+		return PlayResponse{}, errors.Errorf("it is not the turn of %s in round %d, waiting for: %s", p.Name, g.currentRound, strings.Join(names, ", "))
+	}
+	err := g.gameType.ValidateMove(g.state, p.ID, req.Move)
+	if err != nil {
+		return PlayResponse{}, err
+	}
+	logger.Infof("Play for game %s round %d and player %s", req.GameID.String(), req.Round, p.Name)
 	p.currentMove = req.Move
-	playersToMove := playersToMakeMove(g.players)
+	if err := store.Update(g); err != nil {
+		p.currentMove = nil
+		return PlayResponse{}, err
+	}
+	recordMove(g, p, false)
+	playersToMove := playersToMakeMove(g)
 	if len(playersToMove) == 0 {
-		go finishRound(g)
+		finishRound(g)
 	}
 	return PlayResponse{
 		PlayersMove: playersToMove,
@@ -152,47 +317,6 @@ func Play(req PlayRequest) (PlayResponse, error) {
 	}, nil
 }
 
-func getOrCreateGame(token, gameName string, noOfPlayers, totalRounds int) (*game, error) {
-	if token == "" {
-		return nil, errors.New("token is empty")
-	}
-	tokenToGameIDLock.RLock()
-	gameID, ok := tokenToGameID[token]
-	tokenToGameIDLock.RUnlock()
-	if !ok {
-		gameType, err := games.NewGame(gameName)
-		if err != nil {
-			return nil, errors.Wrap(err, "could not create new game")
-		}
-		numberOfPlayers, err := getNumberOfPlayers(gameType, noOfPlayers)
-		if err != nil {
-			return nil, errors.Wrap(err, "could not create new game")
-		}
-
-		if totalRounds <= 0 {
-			totalRounds = gameType.GetDefaultNumberOfRounds()
-		}
-		gameID := uuid.New()
-		tokenToGameIDLock.Lock()
-		tokenToGameID[token] = gameID
-		tokenToGameIDLock.Unlock()
-		gameIDToGameLock.Lock()
-		defer gameIDToGameLock.Unlock()
-		gameIDToGame[gameID] = &game{
-			id:              gameID,
-			gameType:        gameType,
-			numberOfPlayers: numberOfPlayers,
-			players:         make(map[uuid.UUID]*Player),
-			currentRound:    0,
-			totalRounds:     totalRounds,
-		}
-		return gameIDToGame[gameID], nil
-	}
-	gameIDToGameLock.RLock()
-	defer gameIDToGameLock.RUnlock()
-	return gameIDToGame[gameID], nil
-}
-
 func getNumberOfPlayers(gameType games.GameType, noOfPlayers int) (int, error) {
 	if noOfPlayers != 0 {
 		if !gameType.Validate(noOfPlayers) {
@@ -203,9 +327,38 @@ func getNumberOfPlayers(gameType games.GameType, noOfPlayers int) (int, error) {
 	return gameType.GetDefaultNumberOfPlayers(), nil
 }
 
-func playersToMakeMove(players map[uuid.UUID]*Player) []string {
+func getTimeoutPolicy(policy string) (TimeoutPolicy, error) {
+	switch TimeoutPolicy(policy) {
+	case "":
+		return ForfeitRound, nil
+	case ForfeitRound, PlayRandomMove, EndGame:
+		return TimeoutPolicy(policy), nil
+	}
+	return "", errors.Errorf("timeout policy %q is invalid", policy)
+}
+
+// playersInTurn returns the players who have to move in the current round
+func playersInTurn(g *game) []*Player {
+	ids := g.gameType.PlayersToMove(g.state, g.playerOrder, g.currentRound)
+	players := make([]*Player, 0, len(ids))
+	for _, id := range ids {
+		players = append(players, g.players[id])
+	}
+	return players
+}
+
+func hasToMove(g *game, player *Player) bool {
+	for _, p := range playersInTurn(g) {
+		if p == player {
+			return true
+		}
+	}
+	return false
+}
+
+func playersToMakeMove(g *game) []string {
 	var playersToMakeMove = make([]string, 0)
-	for _, p := range players {
+	for _, p := range playersInTurn(g) {
 		if p.currentMove == nil {
 			playersToMakeMove = append(playersToMakeMove, p.Name)
 		}
@@ -214,181 +367,489 @@ func playersToMakeMove(players map[uuid.UUID]*Player) []string {
 }
 
 func finishRound(g *game) {
-	var moves = make([]games.PlayerMove, 0)
-	for id, p := range g.players {
-		moves = append(moves, games.PlayerMove{ID: id, Move: p.currentMove})
+	var moves = make([]games.PlayerMove, 0, len(g.playerOrder))
+	for _, id := range g.playerOrder {
+		moves = append(moves, games.PlayerMove{ID: id, Move: g.players[id].currentMove})
 	}
-	result := g.gameType.EvaluateRound(moves)
-	if result.Status == games.DRAW {
-		logger.Infof("Result for game %s and round %d is DRAW", g.id, g.currentRound)
+	var result games.RoundResult
+	result, g.state = g.gameType.EvaluateRound(g.state, moves)
+	applyRoundResult(g, result, moves)
+}
+
+func applyRoundResult(g *game, result games.RoundResult, moves []games.PlayerMove) {
+	stopRoundTimer(g)
+	if result.Replay {
+		logger.Infof("Result for game %s and round %d is DRAW, the round is replayed", g.id, g.currentRound)
 		oldRound := g.currentRound
 		for _, player := range g.players {
 			player.currentMove = nil
 		}
+		recordRoundFinished(g, oldRound, result, moves)
+		startRoundTimer(g)
 		notifyRoundFinished(g, oldRound, result, moves)
+		notifyYourTurn(g)
+		saveGame(g)
 		return
 	}
 	oldRound := g.currentRound
 	g.currentRound++
-	for _, playerResult := range result.PlayerResults {
-		g.players[playerResult.ID].currentMove = nil
-		if playerResult.Status == games.WIN {
-			g.players[playerResult.ID].score++
-		}
-	}
+	scoreRound(g, result)
+	recordRoundFinished(g, oldRound, result, moves)
 
 	if isGameOver(g) {
-		logger.Infof("Game %s is over, Winner is %s, Score is %s", g.id, g.players[result.Winner].Name, scoreAsString(g.players))
-		removeGame(g)
-		notifyGameFinished(g, result)
+		endGame(g, oldRound, result, moves)
 	} else {
-		logger.Infof("Winner for game %s and round %d is %s, Score is %s", g.id, oldRound, g.players[result.Winner].Name, scoreAsString(g.players))
+		logger.Infof("Winner for game %s and round %d is %s, Score is %s", g.id, oldRound, winnerNames(g, result.PlayerResults), scoreAsString(g))
+		startRoundTimer(g)
 		notifyRoundFinished(g, oldRound, result, moves)
+		notifyYourTurn(g)
+		saveGame(g)
 	}
 }
 
-func tryStartGame(g *game) {
-	reachablePlayers, unreachablePlayers := splitReachableAndUnreachablePlayers(g.players)
-	for i := 10; i > 0; i-- {
-		if len(unreachablePlayers) == 0 {
-			break
+// scoreRound adds the points of the round to the scores of the players, and clears their moves
+func scoreRound(g *game, result games.RoundResult) {
+	for _, playerResult := range result.PlayerResults {
+		p := g.players[playerResult.ID]
+		p.currentMove = nil
+		p.score += playerResult.Points
+	}
+}
+
+// endGame finishes the game after its last round, the scores decide the result of the game
+func endGame(g *game, oldRound int, result games.RoundResult, moves []games.PlayerMove) {
+	gameResults := computeGameResults(g)
+	logger.Infof("Game %s is over, Winner is %s, Score is %s", g.id, winnerNames(g, gameResults), scoreAsString(g))
+	// the players learn the result of the last round from gameFinished, spectators get every round with its moves
+	notifySpectatorsRoundFinished(g, oldRound, result, moves)
+	notifyGameFinished(g, gameResults, result.GameState)
+	removeGame(g, "game finished")
+}
+
+func timeoutRound(g *game) {
+	timedOutPlayers := playersToMakeMove(g)
+	logger.Infof("Round %d of game %s timed out, players that did not move: %s, applying policy %s",
+		g.currentRound, g.id, strings.Join(timedOutPlayers, ", "), g.timeoutPolicy)
+	recordRoundTimeout(g, timedOutPlayers)
+	notifyRoundTimeout(g, timedOutPlayers)
+	switch g.timeoutPolicy {
+	case PlayRandomMove:
+		for _, p := range playersInTurn(g) {
+			if p.currentMove == nil {
+				p.currentMove = g.gameType.RandomMove(g.state, p.ID)
+				recordMove(g, p, true)
+			}
+		}
+		finishRound(g)
+	case EndGame:
+		result, moves := forfeitRound(g)
+		stopRoundTimer(g)
+		oldRound := g.currentRound
+		scoreRound(g, result)
+		recordRoundFinished(g, oldRound, result, moves)
+		endGame(g, oldRound, result, moves)
+	default:
+		result, moves := forfeitRound(g)
+		applyRoundResult(g, result, moves)
+	}
+}
+
+// leaveGame frees the place of the player in a game that did not start yet,
+// otherwise the game ends and everyone else wins it
+func leaveGame(g *game, p *Player) {
+	if g.currentRound == 0 {
+		delete(g.players, p.ID)
+		for i, id := range g.playerOrder {
+			if id == p.ID {
+				g.playerOrder = append(g.playerOrder[:i], g.playerOrder[i+1:]...)
+				break
+			}
+		}
+		saveGame(g)
+		forgetToken(p.ID)
+		recordReplay(g, replayPlayerLeft, newReplayPlayer(p))
+		logger.Infof("Player %s left game %s before it started", p.Name, g.id)
+		return
+	}
+	logger.Infof("Player %s left game %s in round %d, the game is over", p.Name, g.id, g.currentRound)
+	recordReplay(g, replayPlayerLeft, newReplayPlayer(p))
+	gameResults := make([]games.PlayerResult, 0, len(g.playerOrder))
+	for _, id := range g.playerOrder {
+		status := games.WIN
+		if id == p.ID {
+			status = games.LOSE
+		}
+		gameResults = append(gameResults, games.PlayerResult{ID: id, Status: status})
+	}
+	notifyGameFinished(g, gameResults, nil)
+	removeGame(g, "game finished")
+}
+
+// forfeitRound computes the result of a round in which the players who had to move but did not
+// lose against everyone else, who score the forfeit points of the game, the round is replayed when nobody moved,
+// game types that keep the past rounds add the forfeited round to the state of the game
+func forfeitRound(g *game) (games.RoundResult, []games.PlayerMove) {
+	result := games.RoundResult{Status: games.DRAW, Replay: true}
+	var moves = make([]games.PlayerMove, 0, len(g.playerOrder))
+	for _, id := range g.playerOrder {
+		p := g.players[id]
+		moves = append(moves, games.PlayerMove{ID: id, Move: p.currentMove})
+		if p.currentMove == nil && hasToMove(g, p) {
+			result.PlayerResults = append(result.PlayerResults, games.PlayerResult{ID: id, Status: games.LOSE})
+			continue
+		}
+		result.PlayerResults = append(result.PlayerResults, games.PlayerResult{ID: id, Status: games.WIN, Points: g.gameType.ForfeitPoints()})
+		result.Status = games.WIN
+		result.Replay = false
+	}
+	if result.Status == games.DRAW {
+		for i := range result.PlayerResults {
+			result.PlayerResults[i].Status = games.DRAW
+			result.PlayerResults[i].Points = 0
+		}
+	}
+	if recorder, ok := g.gameType.(games.ForfeitRecorder); ok && !result.Replay {
+		result, g.state = recorder.RecordForfeit(g.state, moves, result)
+	}
+	return result, moves
+}
+
+func startRoundTimer(g *game) {
+	stopRoundTimer(g)
+	if g.moveTimeout <= 0 {
+		return
+	}
+	g.roundTimerID++
+	g.roundTimer = g.sendLater(roundTimeoutCommand{timerID: g.roundTimerID}, g.moveTimeout)
+}
+
+func stopRoundTimer(g *game) {
+	if g.roundTimer != nil {
+		g.roundTimer.Stop()
+		g.roundTimer = nil
+	}
+}
+
+// pauseGame stops the round timer until the player reconnects, the player leaves the game after the grace period
+func pauseGame(g *game, p *Player) {
+	logger.Infof("Player %s lost the connection to game %s, pausing the game for %s", p.Name, g.id, reconnectGracePeriod)
+	stopRoundTimer(g)
+	g.graceTimerID++
+	g.pausedBy[p.ID] = g.graceTimerID
+	g.sendLater(graceExpiredCommand{playerID: p.ID, timerID: g.graceTimerID}, reconnectGracePeriod)
+}
+
+// resumeGame restarts the round timer when the last of the disconnected players reconnected
+func resumeGame(g *game, p *Player) {
+	delete(g.pausedBy, p.ID)
+	logger.Infof("Player %s reconnected to game %s", p.Name, g.id)
+	if len(g.pausedBy) == 0 {
+		startRoundTimer(g)
+	}
+}
+
+func pausingPlayerNames(g *game) []string {
+	var names []string
+	for _, id := range g.playerOrder {
+		if _, ok := g.pausedBy[id]; ok {
+			names = append(names, g.players[id].Name)
 		}
-		time.Sleep(1 * time.Second)
-		reachablePlayers, unreachablePlayers = splitReachableAndUnreachablePlayers(g.players)
+	}
+	return names
+}
+
+func tryStartGame(g *game, attemptsLeft int) {
+	reachablePlayers, unreachablePlayers := splitReachableAndUnreachablePlayers(g.players)
+	if len(unreachablePlayers) > 0 && attemptsLeft > 0 {
+		g.sendLater(startCommand{attemptsLeft: attemptsLeft - 1}, time.Second)
+		return
 	}
 	if len(unreachablePlayers) > 0 {
 		logger.Warningf("Game will not start, unreachable players are: %s", strings.Join(unreachablePlayers, ", "))
 		message := fmt.Sprintf("Game will not start and you will need to reconnect, unreachable players are: %s", strings.Join(unreachablePlayers, ", "))
-		notifyError(reachablePlayers, message)
-		removeGame(g)
+		notifyError(g, reachablePlayers, message)
+		recordReplay(g, replayGameAborted, replayAbort{Reason: message})
+		removeGame(g, "game aborted")
 		return
 	}
 	g.currentRound = 1
-	notifyStartGame(g.players, g.id, g.currentRound)
+	g.state = g.gameType.NewState(g.playerOrder, g.options)
+	recordGameStarted(g)
+	startRoundTimer(g)
+	notifyStartGame(g)
+	notifyYourTurn(g)
+	saveGame(g)
 }
 
-func notifyStartGame(players map[uuid.UUID]*Player, gameID uuid.UUID, nextRound int) {
+// notifyStartGame lists the players in the order they joined the game
+func notifyStartGame(g *game) {
 	var subscribers []events.Subscriber
 	var playerNames []string
-	for _, player := range players {
-		subscribers = append(subscribers, events.Subscriber{
-			Callback:      player.EventCallback,
-			WebsocketConn: player.WebsocketConn,
-		})
+	for _, id := range g.playerOrder {
+		player := g.players[id]
+		subscribers = append(subscribers, player.subscriber(g.eventLog))
 		playerNames = append(playerNames, player.Name)
 	}
 	events.PublishStartGame(events.StartGame{
-		GameID:      gameID,
+		GameID:      g.id,
 		Players:     playerNames,
 		Subscribers: subscribers,
-		NextRound:   nextRound,
+		NextRound:   g.currentRound,
+		Spectators:  spectators(g),
 	})
 }
 
-func notifyRoundFinished(g *game, oldRound int, result games.RoundResult, moves []games.PlayerMove) {
-	var winner string
-	if result.Status != games.DRAW {
-		winner = g.players[result.Winner].Name
+func notifyYourTurn(g *game) {
+	var subscribers []events.Subscriber
+	for _, player := range playersInTurn(g) {
+		subscribers = append(subscribers, player.subscriber(g.eventLog))
 	}
+	events.PublishYourTurn(events.YourTurn{
+		GameID:      g.id,
+		Round:       g.currentRound,
+		Subscribers: subscribers,
+	})
+}
+
+func notifyRoundFinished(g *game, oldRound int, result games.RoundResult, moves []games.PlayerMove) {
+	roundFinished := roundFinishedEvent(g, oldRound, result, moves)
+	roundFinished.PlayerResults = computePlayerResults(g, result.PlayerResults)
+	events.PublishRoundFinished(roundFinished)
+}
+
+func notifySpectatorsRoundFinished(g *game, oldRound int, result games.RoundResult, moves []games.PlayerMove) {
+	events.PublishRoundFinished(roundFinishedEvent(g, oldRound, result, moves))
+}
+
+func roundFinishedEvent(g *game, oldRound int, result games.RoundResult, moves []games.PlayerMove) events.RoundFinished {
 	movesMap := make(map[string]interface{}, len(moves))
 	for _, move := range moves {
-		movesMap[g.players[move.ID].Name] = move.Move
+		if move.Move != nil {
+			movesMap[g.players[move.ID].Name] = move.Move
+		}
+	}
+	pointsMap := make(map[string]int, len(result.PlayerResults))
+	for _, playerResult := range result.PlayerResults {
+		pointsMap[g.players[playerResult.ID].Name] = playerResult.Points
 	}
-	events.PublishRoundFinished(events.RoundFinished{
+	return events.RoundFinished{
+		GameID:       g.id,
+		CurrentRound: oldRound,
+		NextRound:    g.currentRound,
+		Scores:       computeScores(g),
+		Winner:       winnerNames(g, result.PlayerResults),
+		Moves:        movesMap,
+		Points:       pointsMap,
+		GameState:    result.GameState,
+		Status:       string(result.Status),
+		Spectators:   spectators(g),
+	}
+}
+
+func notifyGameFinished(g *game, gameResults []games.PlayerResult, gameState interface{}) {
+	winner := winnerNames(g, gameResults)
+	status := games.WIN
+	if winner == "" {
+		status = games.DRAW
+	}
+	events.PublishGameFinished(events.GameFinished{
 		GameID:        g.id,
-		CurrentRound:  oldRound,
-		NextRound:     g.currentRound,
-		PlayerResults: computePlayerResults(g.players, result),
+		PlayerResults: computePlayerResults(g, gameResults),
+		Scores:        computeScores(g),
 		Winner:        winner,
-		Moves:         movesMap,
+		GameState:     gameState,
+		Status:        string(status),
+		Spectators:    spectators(g),
 	})
+	recordGameFinished(g, gameResults, status, winner, gameState)
+	g.finished = true
+	updateRatings(g, gameResults)
+	reportTournamentGame(g, gameResults)
 }
 
-func notifyGameFinished(g *game, result games.RoundResult) {
-	events.PublishGameFinished(events.GameFinished{
-		GameID:        g.id,
-		PlayerResults: computePlayerResults(g.players, result),
-		Winner:        g.players[result.Winner].Name,
+func notifyRoundTimeout(g *game, timedOutPlayers []string) {
+	var subscribers []events.Subscriber
+	for _, player := range g.players {
+		subscribers = append(subscribers, player.subscriber(g.eventLog))
+	}
+	events.PublishRoundTimeout(events.RoundTimeout{
+		GameID:          g.id,
+		Round:           g.currentRound,
+		Policy:          string(g.timeoutPolicy),
+		TimedOutPlayers: timedOutPlayers,
+		Subscribers:     subscribers,
 	})
 }
 
-func notifyError(players map[uuid.UUID]*Player, message string) {
+func notifyError(g *game, players map[uuid.UUID]*Player, message string) {
 	var subscribers []events.Subscriber
 	for _, player := range players {
-		subscribers = append(subscribers, events.Subscriber{
-			Callback:      player.EventCallback,
-			WebsocketConn: player.WebsocketConn,
-		})
+		subscribers = append(subscribers, player.subscriber(g.eventLog))
 	}
 	events.PublishError(subscribers, message)
 }
 
-func computePlayerResults(players map[uuid.UUID]*Player, result games.RoundResult) []events.PlayerResult {
+func spectators(g *game) events.Spectators {
+	spectators := events.Spectators{Log: g.eventLog}
+	for transport := range g.spectators {
+		spectators.Transports = append(spectators.Transports, transport)
+	}
+	return spectators
+}
+
+func computePlayerResults(g *game, results []games.PlayerResult) []events.PlayerResult {
 	var playerResults []events.PlayerResult
-	for _, playerResult := range result.PlayerResults {
-		scores := []string{strconv.Itoa(players[playerResult.ID].score)}
-		for id, p := range players {
-			if id != playerResult.ID {
-				scores = append(scores, strconv.Itoa(p.score))
-			}
+	for _, playerResult := range results {
+		player := g.players[playerResult.ID]
+		var legacyScore string
+		if player.LegacyScore {
+			legacyScore = legacyScoreAsString(g, player)
 		}
 		playerResults = append(playerResults, events.PlayerResult{
-			Status: string(playerResult.Status),
-			Score:  strings.Join(scores, "-"),
-			Subscriber: events.Subscriber{
-				Callback:      players[playerResult.ID].EventCallback,
-				WebsocketConn: players[playerResult.ID].WebsocketConn,
-			},
+			Status:     string(playerResult.Status),
+			Score:      legacyScore,
+			Subscriber: player.subscriber(g.eventLog),
 		})
 	}
 	return playerResults
 }
 
-func scoreAsString(players map[uuid.UUID]*Player) string {
-	var scores []string
-	for _, p := range players {
-		scores = append(scores, strconv.Itoa(p.score))
+// legacyScoreAsString joins the scores with a dash, the score of the given player comes first,
+// followed by the others in the order they joined the game
+func legacyScoreAsString(g *game, player *Player) string {
+	scores := []string{strconv.Itoa(player.score)}
+	for _, id := range g.playerOrder {
+		if id != player.ID {
+			scores = append(scores, strconv.Itoa(g.players[id].score))
+		}
 	}
 	return strings.Join(scores, "-")
 }
 
-func isGameOver(g *game) bool {
-	if g.currentRound > g.totalRounds {
-		return true
+// computeScores ranks the players by their score, players with the same score share the rank,
+// the next rank skips the shared places (1, 1, 3)
+func computeScores(g *game) []events.Score {
+	scores := make([]events.Score, 0, len(g.playerOrder))
+	for _, id := range g.playerOrder {
+		p := g.players[id]
+		scores = append(scores, events.Score{PlayerName: p.Name, PlayerID: p.ID, BotID: p.BotID, Score: p.score})
 	}
-	for _, p := range g.players {
-		if p.score > (g.totalRounds-1)/2 {
-			return true
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	for i := range scores {
+		if i > 0 && scores[i].Score == scores[i-1].Score {
+			scores[i].Rank = scores[i-1].Rank
+		} else {
+			scores[i].Rank = i + 1
 		}
 	}
-	return false
+	return scores
+}
+
+// computeGameResults decides the outcome of the game based on the score,
+// the players with the highest score win, unless everybody has the same score
+func computeGameResults(g *game) []games.PlayerResult {
+	highestScore, lowestScore := 0, 0
+	for i, id := range g.playerOrder {
+		score := g.players[id].score
+		if i == 0 || score > highestScore {
+			highestScore = score
+		}
+		if i == 0 || score < lowestScore {
+			lowestScore = score
+		}
+	}
+	gameResults := make([]games.PlayerResult, 0, len(g.playerOrder))
+	for _, id := range g.playerOrder {
+		status := games.LOSE
+		if highestScore == lowestScore {
+			status = games.DRAW
+		} else if g.players[id].score == highestScore {
+			status = games.WIN
+		}
+		gameResults = append(gameResults, games.PlayerResult{ID: id, Status: status, Points: g.players[id].score})
+	}
+	return gameResults
+}
+
+func winnerNames(g *game, results []games.PlayerResult) string {
+	var winners []string
+	for _, result := range results {
+		if result.Status == games.WIN {
+			winners = append(winners, g.players[result.ID].Name)
+		}
+	}
+	return strings.Join(winners, ", ")
+}
+
+func scoreAsString(g *game) string {
+	var scores []string
+	for _, id := range g.playerOrder {
+		scores = append(scores, strconv.Itoa(g.players[id].score))
+	}
+	return strings.Join(scores, "-")
+}
+
+// isGameOver lets the game type decide, based on the scores and the rounds played, if the game is over
+func isGameOver(g *game) bool {
+	scores := make(map[uuid.UUID]int, len(g.players))
+	for id, p := range g.players {
+		scores[id] = p.score
+	}
+	return g.gameType.IsGameOver(g.state, games.Progress{
+		RoundsPlayed: g.currentRound - 1,
+		TotalRounds:  g.totalRounds,
+		Scores:       scores,
+	})
 }
 
 func splitReachableAndUnreachablePlayers(players map[uuid.UUID]*Player) (map[uuid.UUID]*Player, []string) {
 	reachablePlayers := make(map[uuid.UUID]*Player, 0)
 	var unreachablePlayers []string
 	for id, player := range players {
-		if (player.EventCallback == nil || !player.EventCallback.IsAbs()) && (player.WebsocketConn == nil) {
-			unreachablePlayers = append(unreachablePlayers, player.Name)
-		} else {
+		if isReachable(player) {
 			reachablePlayers[id] = player
+		} else {
+			unreachablePlayers = append(unreachablePlayers, player.Name)
 		}
 	}
 	return reachablePlayers, unreachablePlayers
 }
 
-func removeGame(g *game) {
-	t := ""
-	tokenToGameIDLock.Lock()
-	for token, gameID := range tokenToGameID {
-		if gameID == g.id {
-			t = token
-			break
+// isReachable tells whether the player gets the events of the game in some way
+func isReachable(p *Player) bool {
+	return (p.Transport != nil && p.Transport.Healthy()) || (p.callback != nil && p.callback.Healthy()) || p.Polling
+}
+
+func saveGame(g *game) {
+	if err := store.Update(g); err != nil {
+		logger.Error(errors.Wrapf(err, "could not save game %s", g.id))
+	}
+}
+
+// removeGame stops the game, the connections of the players and spectators are closed with the reason after their last events
+func removeGame(g *game, reason string) {
+	if err := store.Remove(g.id); err != nil {
+		logger.Error(errors.Wrapf(err, "could not remove game %s", g.id))
+	}
+	stopRoundTimer(g)
+	for _, p := range g.players {
+		if p.Transport != nil {
+			events.CloseTransport(p.Transport, reason)
+			p.Transport = nil
 		}
 	}
-	delete(tokenToGameID, t)
-	tokenToGameIDLock.Unlock()
-	gameIDToGameLock.Lock()
-	delete(gameIDToGame, g.id)
-	gameIDToGameLock.Unlock()
+	for transport := range g.spectators {
+		events.CloseTransport(transport, reason)
+		delete(g.spectators, transport)
+	}
+	g.eventLog.Close(g.id)
+	closeReplay(g.id)
+	forgetTokensLater(g)
+	// a game removed without a result is reported to its tournament here, a finished one already was
+	if !g.finished {
+		reportTournamentGame(g, nil)
+	}
+	g.stop()
 }
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"botServer/web/model"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+// sendTimeout is how long Send waits for a full channel to be read before closing the transport
+const sendTimeout = 10 * time.Second
+
+// ChannelTransport hands the events to a reader in the same process, such as a request streaming them or a test
+type ChannelTransport struct {
+	events    chan model.Event
+	done      chan struct{}
+	closeOnce sync.Once
+	// reason is why the transport was closed, it is set before done is closed
+	reason string
+}
+
+// NewChannelTransport creates an open transport, buffer events can wait to be read
+func NewChannelTransport(buffer int) *ChannelTransport {
+	return &ChannelTransport{
+		events: make(chan model.Event, buffer),
+		done:   make(chan struct{}),
+	}
+}
+
+// Events returns the events sent through the transport
+func (t *ChannelTransport) Events() <-chan model.Event {
+	return t.events
+}
+
+// Done is closed when the transport is closed
+func (t *ChannelTransport) Done() <-chan struct{} {
+	return t.done
+}
+
+// Send waits for room in the channel, a transport that is not read for sendTimeout is closed
+func (t *ChannelTransport) Send(event model.Event) error {
+	timer := time.NewTimer(sendTimeout)
+	defer timer.Stop()
+	select {
+	case t.events <- event:
+		return nil
+	case <-t.done:
+		return errors.New("channel transport is closed")
+	case <-timer.C:
+		_ = t.Close("not read")
+		return errors.New("channel transport is not read, closing it")
+	}
+}
+
+// Close stops the transport, the events already in the channel can still be read
+func (t *ChannelTransport) Close(reason string) error {
+	t.closeOnce.Do(func() {
+		t.reason = reason
+		close(t.done)
+	})
+	return nil
+}
+
+// Reason tells why the transport was closed, it can only be called once Done is closed
+func (t *ChannelTransport) Reason() string {
+	return t.reason
+}
+
+// Healthy tells whether the transport is still open
+func (t *ChannelTransport) Healthy() bool {
+	select {
+	case <-t.done:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"botServer/web/model"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+// maxDeadLetters is how many dead letters are kept, the oldest ones are dropped first
+const maxDeadLetters = 1000
+
+// DeadLetter is an event that could not be delivered to a callback
+type DeadLetter struct {
+	// ID is the event id sent in the X-Bot-Event-Id header
+	ID       uuid.UUID
+	Callback string
+	Event    model.Event
+	Attempts int
+	Error    string
+	FailedAt time.Time
+}
+
+var (
+	deadLettersLock sync.Mutex
+	deadLetters     []DeadLetter
+)
+
+// DeadLetters returns the events that could not be delivered, the oldest first
+func DeadLetters() []DeadLetter {
+	deadLettersLock.Lock()
+	defer deadLettersLock.Unlock()
+	return append([]DeadLetter(nil), deadLetters...)
+}
+
+func addDeadLetter(id uuid.UUID, callback string, event model.Event, attempts int, err error) {
+	deadLettersLock.Lock()
+	defer deadLettersLock.Unlock()
+	if len(deadLetters) == maxDeadLetters {
+		deadLetters = deadLetters[1:]
+	}
+	deadLetters = append(deadLetters, DeadLetter{
+		ID:       id,
+		Callback: callback,
+		Event:    event,
+		Attempts: attempts,
+		Error:    err.Error(),
+		FailedAt: time.Now(),
+	})
+}
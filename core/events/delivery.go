@@ -0,0 +1,79 @@
+package events
+
+import (
+	"botServer/web/model"
+	"github.com/google/logger"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+var (
+	queuesLock sync.Mutex
+	// queues has a delivery queue for every transport with events pending
+	queues = make(map[Transport]*deliveryQueue)
+)
+
+// deliveryQueue delivers the events of a transport one by one, in the order they were published,
+// an event that cannot be delivered holds back the ones after it until the transport gives up on it
+type deliveryQueue struct {
+	pending []delivery
+}
+
+// delivery sends an event, or closes the transport when close is set
+type delivery struct {
+	transport Transport
+	event     model.Event
+	close     bool
+	reason    string
+}
+
+// enqueue adds the event to the queue of the transport
+func enqueue(transport Transport, event model.Event) {
+	push(delivery{transport: transport, event: event})
+}
+
+// CloseTransport closes the transport with the reason once the events queued for it are delivered
+func CloseTransport(transport Transport, reason string) {
+	push(delivery{transport: transport, close: true, reason: reason})
+}
+
+// push adds the delivery to the queue of its transport, starting a worker for the queue if it has none
+func push(d delivery) {
+	queuesLock.Lock()
+	defer queuesLock.Unlock()
+	q, ok := queues[d.transport]
+	if !ok {
+		q = &deliveryQueue{}
+		queues[d.transport] = q
+		go q.run(d.transport)
+	}
+	q.pending = append(q.pending, d)
+}
+
+// run delivers the pending events, and removes the queue when there are none left
+func (q *deliveryQueue) run(transport Transport) {
+	for {
+		queuesLock.Lock()
+		if len(q.pending) == 0 {
+			delete(queues, transport)
+			queuesLock.Unlock()
+			return
+		}
+		d := q.pending[0]
+		q.pending = q.pending[1:]
+		queuesLock.Unlock()
+		d.deliver()
+	}
+}
+
+func (d delivery) deliver() {
+	if d.close {
+		if err := d.transport.Close(d.reason); err != nil {
+			logger.Error(errors.Wrap(err, "closing transport failed"))
+		}
+		return
+	}
+	if err := d.transport.Send(d.event); err != nil {
+		logger.Error(errors.Wrapf(err, "publishing %s with seq %d failed", d.event.Type, d.event.Seq))
+	}
+}
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"botServer/web/model"
+	"context"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+// logRetention is how long the log of a finished game can still be read, so that polling players get the last events
+const logRetention = 10 * time.Minute
+
+var (
+	logsLock sync.Mutex
+	logs     = make(map[uuid.UUID]*GameLog)
+)
+
+// GameLog numbers the events of a game, and keeps the events sent to every player of it
+type GameLog struct {
+	lock    sync.Mutex
+	seq     int
+	players map[uuid.UUID][]model.Event
+	closed  bool
+	// changed is closed and replaced whenever an event is added or the log is closed
+	changed chan struct{}
+}
+
+// NewGameLog creates the log of the given game, numbering its events after lastSeq
+func NewGameLog(gameID uuid.UUID, lastSeq int) *GameLog {
+	l := NewLog(lastSeq)
+	logsLock.Lock()
+	defer logsLock.Unlock()
+	logs[gameID] = l
+	return l
+}
+
+// NewLog creates a log that is not found by GetGameLog, like the one of a tournament,
+// its owner keeps it
+func NewLog(lastSeq int) *GameLog {
+	return &GameLog{
+		seq:     lastSeq,
+		players: make(map[uuid.UUID][]model.Event),
+		changed: make(chan struct{}),
+	}
+}
+
+// GetGameLog returns the log of the given game, it is kept for a while after the game finished
+func GetGameLog(gameID uuid.UUID) (*GameLog, bool) {
+	logsLock.Lock()
+	defer logsLock.Unlock()
+	l, ok := logs[gameID]
+	return l, ok
+}
+
+// AddPlayer starts keeping the events sent to the given player
+func (l *GameLog) AddPlayer(playerID uuid.UUID) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, ok := l.players[playerID]; !ok {
+		l.players[playerID] = nil
+	}
+}
+
+// HasPlayer tells whether the events of the given player are kept
+func (l *GameLog) HasPlayer(playerID uuid.UUID) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	_, ok := l.players[playerID]
+	return ok
+}
+
+// LastSeq returns the sequence number of the last event of the game
+func (l *GameLog) LastSeq() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.seq
+}
+
+// Since returns the events sent to the player with a sequence number greater than the given one
+func (l *GameLog) Since(playerID uuid.UUID, seq int) []model.Event {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.since(playerID, seq)
+}
+
+// Wait returns the events sent to the player after the given sequence number, waiting at most timeout
+// for one to be published, the result is empty when none was or when the game finished
+func (l *GameLog) Wait(ctx context.Context, playerID uuid.UUID, seq int, timeout time.Duration) []model.Event {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		l.lock.Lock()
+		events, closed, changed := l.since(playerID, seq), l.closed, l.changed
+		l.lock.Unlock()
+		if len(events) > 0 || closed {
+			return events
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Close marks the game as finished, its log is dropped after logRetention
+func (l *GameLog) Close(gameID uuid.UUID) {
+	l.lock.Lock()
+	if !l.closed {
+		l.closed = true
+		close(l.changed)
+	}
+	l.lock.Unlock()
+	time.AfterFunc(logRetention, func() {
+		logsLock.Lock()
+		defer logsLock.Unlock()
+		if logs[gameID] == l {
+			delete(logs, gameID)
+		}
+	})
+}
+
+func (l *GameLog) since(playerID uuid.UUID, seq int) []model.Event {
+	events := l.players[playerID]
+	// the events are sorted by sequence number, but the numbers of a player have gaps
+	for i, event := range events {
+		if event.Seq > seq {
+			return append([]model.Event(nil), events[i:]...)
+		}
+	}
+	return nil
+}
+
+func (l *GameLog) next() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.seq++
+	return l.seq
+}
+
+func (l *GameLog) append(playerID uuid.UUID, event model.Event) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.players[playerID] = append(l.players[playerID], event)
+	if !l.closed {
+		close(l.changed)
+		l.changed = make(chan struct{})
+	}
+}
@@ -0,0 +1,59 @@
+package events
+
+import (
+	"botServer/web/model"
+	"context"
+	"github.com/google/uuid"
+	"testing"
+	"time"
+)
+
+// TestSinceReturnsEventsAfterSeq checks the events a reconnecting or polling player gets again
+func TestSinceReturnsEventsAfterSeq(t *testing.T) {
+	gameID := uuid.New()
+	// the numbering goes on after the last seq of a game restored after a restart
+	log := NewGameLog(gameID, 5)
+	playerID := uuid.New()
+	log.AddPlayer(playerID)
+	subscriber := Subscriber{Log: log, PlayerID: playerID}
+	other := Subscriber{Log: log, PlayerID: uuid.New()}
+	PublishYourTurn(YourTurn{GameID: gameID, Round: 1, Subscribers: []Subscriber{subscriber}})
+	PublishYourTurn(YourTurn{GameID: gameID, Round: 1, Subscribers: []Subscriber{other}})
+	PublishYourTurn(YourTurn{GameID: gameID, Round: 2, Subscribers: []Subscriber{subscriber}})
+
+	assertSeqs(t, "since 0", log.Since(playerID, 0), 6, 8)
+	assertSeqs(t, "since 6", log.Since(playerID, 6), 8)
+	// a seq in a gap of the player returns the events after it
+	assertSeqs(t, "since 7", log.Since(playerID, 7), 8)
+	assertSeqs(t, "since 8", log.Since(playerID, 8))
+	if l, ok := GetGameLog(gameID); !ok || l != log {
+		t.Error("the log of the game is not registered")
+	}
+}
+
+// TestWaitReturnsPublishedEvent checks that a waiting poll gets an event published while it waits,
+// and that it returns without events once the game is finished
+func TestWaitReturnsPublishedEvent(t *testing.T) {
+	gameID := uuid.New()
+	log := NewGameLog(gameID, 0)
+	subscriber := Subscriber{Log: log, PlayerID: uuid.New()}
+	log.AddPlayer(subscriber.PlayerID)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		PublishYourTurn(YourTurn{GameID: gameID, Round: 1, Subscribers: []Subscriber{subscriber}})
+	}()
+	events := log.Wait(context.Background(), subscriber.PlayerID, 0, receiveWait)
+	assertSeqs(t, "wait", events, 1)
+	if round := events[0].Body.(model.YourTurn).Round; round != 1 {
+		t.Errorf("got yourTurn of round %d, want 1", round)
+	}
+
+	log.Close(gameID)
+	start := time.Now()
+	if events := log.Wait(context.Background(), subscriber.PlayerID, 1, receiveWait); len(events) != 0 {
+		t.Errorf("got %d events after the game finished, want none", len(events))
+	}
+	if waited := time.Since(start); waited >= receiveWait {
+		t.Errorf("waited %s for a finished game", waited)
+	}
+}
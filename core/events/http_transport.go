@@ -0,0 +1,115 @@
+package events
+
+import (
+	"botServer/web/model"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	deliveryTimeout     = 5 * time.Second
+	maxDeliveryAttempts = 5
+	initialBackoff      = 500 * time.Millisecond
+)
+
+var httpClient = &http.Client{Timeout: deliveryTimeout}
+
+// HTTPTransport posts the events to a callback, retrying with exponential backoff,
+// the events that could not be delivered or that the callback rejected are dead lettered
+type HTTPTransport struct {
+	callback *url.URL
+	// signingSecret signs the events, they are not signed when empty
+	signingSecret string
+	// failing is 1 while the last event was dead lettered
+	failing int32
+	closed  int32
+}
+
+// NewHTTPTransport creates a transport posting to the given absolute callback
+func NewHTTPTransport(callback *url.URL, signingSecret string) *HTTPTransport {
+	return &HTTPTransport{callback: callback, signingSecret: signingSecret}
+}
+
+// Send posts the event, every attempt carries the same event id so the callback can recognize a retried event
+func (t *HTTPTransport) Send(event model.Event) error {
+	callback := t.callback.String()
+	body, err := json.Marshal(event)
+	if err != nil {
+		msg := fmt.Sprintf("publishing: could not encode %+v", event)
+		return errors.Wrap(err, msg)
+	}
+	id := uuid.New()
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		err = post(callback, body, id, t.signingSecret)
+		if err == nil {
+			atomic.StoreInt32(&t.failing, 0)
+			return nil
+		}
+		logger.Warning(errors.Wrapf(err, "publishing %s to %s, attempt %d of %d", event.Type, callback, attempt, maxDeliveryAttempts))
+		if _, rejected := errors.Cause(err).(rejectedError); rejected || attempt == maxDeliveryAttempts {
+			atomic.StoreInt32(&t.failing, 1)
+			addDeadLetter(id, callback, event, attempt, err)
+			return errors.Wrapf(err, "event dead lettered after %d attempts", attempt)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// Close stops counting the callback as a way to reach the subscriber
+func (t *HTTPTransport) Close(string) error {
+	atomic.StoreInt32(&t.closed, 1)
+	return nil
+}
+
+// Healthy tells whether the last event could be delivered
+func (t *HTTPTransport) Healthy() bool {
+	return atomic.LoadInt32(&t.closed) == 0 && atomic.LoadInt32(&t.failing) == 0
+}
+
+// rejectedError is returned when the callback refused the event, sending it again would get the same answer
+type rejectedError struct {
+	statusCode int
+}
+
+func (e rejectedError) Error() string {
+	return fmt.Sprintf("callback rejected the event with status code %d (%s)", e.statusCode, http.StatusText(e.statusCode))
+}
+
+// post sends the event once, the client errors other than 408 and 429 are rejections that are not retried
+func post(callback string, body []byte, eventID uuid.UUID, signingSecret string) error {
+	req, err := http.NewRequest(http.MethodPost, callback, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "publishing through HTTP failed")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(eventIDHeader, eventID.String())
+	if signingSecret != "" {
+		sign(req, body, eventID, signingSecret)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "publishing through HTTP failed")
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+		return rejectedError{statusCode: resp.StatusCode}
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return errors.Errorf("callback answered with status code %d (%s)", resp.StatusCode, http.StatusText(resp.StatusCode))
+	case resp.StatusCode != http.StatusNoContent:
+		msg := fmt.Sprintf("expecting status code 204 (No content) but got %d", resp.StatusCode)
+		logger.Warning(errors.New("publishing: " + msg))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"botServer/web/model"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestHTTPTransportRetriesOnlyTemporaryFailures answers the first attempt of every event with the given status,
+// the rejected events are dead lettered at once, the others are delivered by the second attempt
+func TestHTTPTransportRetriesOnlyTemporaryFailures(t *testing.T) {
+	tests := []struct {
+		status   int
+		attempts int32
+		rejected bool
+	}{
+		{status: http.StatusBadRequest, attempts: 1, rejected: true},
+		{status: http.StatusNotFound, attempts: 1, rejected: true},
+		{status: http.StatusRequestTimeout, attempts: 2},
+		{status: http.StatusTooManyRequests, attempts: 2},
+		{status: http.StatusServiceUnavailable, attempts: 2},
+	}
+	for _, test := range tests {
+		t.Run(http.StatusText(test.status), func(t *testing.T) {
+			var attempts int32
+			callbacks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) == 1 {
+					w.WriteHeader(test.status)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer callbacks.Close()
+			callback, err := url.Parse(callbacks.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = NewHTTPTransport(callback, "").Send(model.Event{Type: "yourTurn"})
+			if got := atomic.LoadInt32(&attempts); got != test.attempts {
+				t.Errorf("the event was posted %d times, want %d", got, test.attempts)
+			}
+			if !test.rejected {
+				if err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), http.StatusText(test.status)) {
+				t.Errorf("got error %v, want one with the status %d", err, test.status)
+			}
+			if !isDeadLettered(callbacks.URL) {
+				t.Error("the rejected event was not dead lettered")
+			}
+		})
+	}
+}
+
+// isDeadLettered tells whether an event posted to the callback was dead lettered
+func isDeadLettered(callback string) bool {
+	for _, deadLetter := range DeadLetters() {
+		if deadLetter.Callback == callback {
+			return true
+		}
+	}
+	return false
+}
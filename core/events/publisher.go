@@ -3,22 +3,27 @@ package events
 
 import (
 	"botServer/web/model"
-	"bytes"
-	"encoding/json"
-	"fmt"
 	"github.com/google/logger"
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
-	"github.com/pkg/errors"
-	"net/http"
-	"net/url"
-	"time"
 )
 
 // Subscriber represents an entity that will be notified with events
 type Subscriber struct {
-	Callback      *url.URL
-	WebsocketConn *websocket.Conn
+	// Transport delivers the events, they are only kept in the log when nil
+	Transport Transport
+	// Log numbers the events of the game and keeps the ones sent to the subscriber, not used when nil
+	Log      *GameLog
+	PlayerID uuid.UUID
+}
+
+// SpectatorID is the id the events sent to the spectators of a game are kept under in its log
+var SpectatorID = uuid.Nil
+
+// Spectators are the clients watching a game, they get its events without the data specific to a player
+type Spectators struct {
+	// Log keeps the events sent to the spectators, so that a spectator joining later gets them too
+	Log        *GameLog
+	Transports []Transport
 }
 
 // StartGame is an intermediate structure for the StartGame event
@@ -27,6 +32,14 @@ type StartGame struct {
 	NextRound   int
 	Players     []string
 	Subscribers []Subscriber
+	Spectators  Spectators
+}
+
+// YourTurn is an intermediate structure for the YourTurn event
+type YourTurn struct {
+	GameID      uuid.UUID
+	Round       int
+	Subscribers []Subscriber
 }
 
 // RoundFinished is an intermediate structure for the RoundFinished event
@@ -35,34 +48,140 @@ type RoundFinished struct {
 	CurrentRound  int
 	NextRound     int
 	PlayerResults []PlayerResult
+	Scores        []Score
 	Winner        string
 	Moves         map[string]interface{}
+	Points        map[string]int
+	GameState     interface{}
+	// Status is the result of the round for the spectators
+	Status     string
+	Spectators Spectators
 }
 
 // GameFinished is an intermediate structure for the GameFinished event
 type GameFinished struct {
 	GameID        uuid.UUID
 	PlayerResults []PlayerResult
+	Scores        []Score
 	Winner        string
+	GameState     interface{}
+	// Status is the result of the game for the spectators
+	Status     string
+	Spectators Spectators
+}
+
+// RoundTimeout is an intermediate structure for the RoundTimeout event
+type RoundTimeout struct {
+	GameID          uuid.UUID
+	Round           int
+	Policy          string
+	TimedOutPlayers []string
+	Subscribers     []Subscriber
+}
+
+// MatchFound is an intermediate structure for the MatchFound event, sent to a bot waiting in the matchmaking queue
+type MatchFound struct {
+	TicketID   uuid.UUID
+	GameID     uuid.UUID
+	PlayerID   uuid.UUID
+	GameName   string
+	Players    []string
+	Rounds     int
+	Subscriber Subscriber
+}
+
+// TournamentUpdate is an intermediate structure for the TournamentUpdate event
+type TournamentUpdate struct {
+	TournamentID uuid.UUID
+	Status       string
+	Round        int
+	Standings    []Standing
+	Games        []TournamentGame
+	Subscribers  []TournamentSubscriber
+}
+
+// TournamentSubscriber is a bot of a tournament, with the game it plays in the current round
+type TournamentSubscriber struct {
+	Subscriber Subscriber
+	// Game is nil when the bot has no game to play in the round
+	Game *PlayerGame
+}
+
+// PlayerGame identifies a player of a game
+type PlayerGame struct {
+	GameID   uuid.UUID
+	PlayerID uuid.UUID
+}
+
+// Standing holds the place of a bot in a tournament
+type Standing struct {
+	Rank       int
+	BotID      uuid.UUID
+	BotName    string
+	Points     float64
+	Wins       int
+	Losses     int
+	Draws      int
+	Byes       int
+	Eliminated bool
+}
+
+// TournamentGame is a game of a tournament
+type TournamentGame struct {
+	Round   int
+	GameID  uuid.UUID
+	Players []string
+	Status  string
+	// Winner is empty while the game is played and for a draw
+	Winner string
 }
 
 // PlayerResult holds the data specific to a player
 // in the context of a RoundFinished or GameFinished event
 type PlayerResult struct {
-	Status     string
+	Status string
+	// Score is the legacy dash separated score, empty if the player did not ask for it
 	Score      string
 	Subscriber Subscriber
 }
 
+// Score holds the total score and the rank of a player
+type Score struct {
+	PlayerName string
+	PlayerID   uuid.UUID
+	// BotID is uuid.Nil for anonymous players
+	BotID uuid.UUID
+	Score int
+	Rank  int
+}
+
 // PublishStartGame publishes the StartGame event
 func PublishStartGame(startGame StartGame) {
+	event := model.Event{
+		Seq:  startGame.Spectators.nextSeq(startGame.Subscribers),
+		Type: "startGame",
+		Body: model.StartGame{
+			GameID:    startGame.GameID.String(),
+			NextRound: startGame.NextRound,
+			Players:   startGame.Players,
+		},
+	}
 	for _, subscriber := range startGame.Subscribers {
+		publish(subscriber, event)
+	}
+	startGame.Spectators.publish(event)
+}
+
+// PublishYourTurn publishes the YourTurn event
+func PublishYourTurn(yourTurn YourTurn) {
+	seq := nextSeq(yourTurn.Subscribers)
+	for _, subscriber := range yourTurn.Subscribers {
 		publish(subscriber, model.Event{
-			Type: "startGame",
-			Body: model.StartGame{
-				GameID:    startGame.GameID.String(),
-				NextRound: startGame.NextRound,
-				Players:   startGame.Players,
+			Seq:  seq,
+			Type: "yourTurn",
+			Body: model.YourTurn{
+				GameID: yourTurn.GameID.String(),
+				Round:  yourTurn.Round,
 			},
 		})
 	}
@@ -74,36 +193,94 @@ func PublishRoundFinished(roundFinished RoundFinished) {
 	for player, move := range roundFinished.Moves {
 		moves[player] = model.Move{Value: move.(string)}
 	}
+	scores := toModelScores(roundFinished.Scores)
+	seq := roundFinished.Spectators.nextSeq(resultSubscribers(roundFinished.PlayerResults))
 	for _, playerResult := range roundFinished.PlayerResults {
 		publish(playerResult.Subscriber, model.Event{
+			Seq:  seq,
 			Type: "roundFinished",
 			Body: model.RoundFinished{
 				GameID:       roundFinished.GameID.String(),
 				CurrentRound: roundFinished.CurrentRound,
 				NextRound:    roundFinished.NextRound,
+				Scores:       scores,
 				Score:        playerResult.Score,
 				RoundResult: model.Result{
 					Winner: roundFinished.Winner,
 					Status: playerResult.Status,
 					Moves:  moves,
+					Points: roundFinished.Points,
 				},
+				GameState: roundFinished.GameState,
 			},
 		})
 	}
+	roundFinished.Spectators.publish(model.Event{
+		Seq:  seq,
+		Type: "roundFinished",
+		Body: model.RoundFinished{
+			GameID:       roundFinished.GameID.String(),
+			CurrentRound: roundFinished.CurrentRound,
+			NextRound:    roundFinished.NextRound,
+			Scores:       scores,
+			RoundResult: model.Result{
+				Winner: roundFinished.Winner,
+				Status: roundFinished.Status,
+				Moves:  moves,
+				Points: roundFinished.Points,
+			},
+			GameState: roundFinished.GameState,
+		},
+	})
 }
 
 // PublishGameFinished publishes the GameFinished event
 func PublishGameFinished(gameFinished GameFinished) {
+	scores := toModelScores(gameFinished.Scores)
+	seq := gameFinished.Spectators.nextSeq(resultSubscribers(gameFinished.PlayerResults))
 	for _, playerResult := range gameFinished.PlayerResults {
 		publish(playerResult.Subscriber, model.Event{
+			Seq:  seq,
 			Type: "gameFinished",
 			Body: model.GameFinished{
 				GameID: gameFinished.GameID.String(),
+				Scores: scores,
 				Score:  playerResult.Score,
 				GameResult: model.Result{
 					Status: playerResult.Status,
 					Winner: gameFinished.Winner,
 				},
+				GameState: gameFinished.GameState,
+			},
+		})
+	}
+	gameFinished.Spectators.publish(model.Event{
+		Seq:  seq,
+		Type: "gameFinished",
+		Body: model.GameFinished{
+			GameID: gameFinished.GameID.String(),
+			Scores: scores,
+			GameResult: model.Result{
+				Status: gameFinished.Status,
+				Winner: gameFinished.Winner,
+			},
+			GameState: gameFinished.GameState,
+		},
+	})
+}
+
+// PublishRoundTimeout publishes the RoundTimeout event
+func PublishRoundTimeout(roundTimeout RoundTimeout) {
+	seq := nextSeq(roundTimeout.Subscribers)
+	for _, subscriber := range roundTimeout.Subscribers {
+		publish(subscriber, model.Event{
+			Seq:  seq,
+			Type: "roundTimeout",
+			Body: model.RoundTimeout{
+				GameID:          roundTimeout.GameID.String(),
+				Round:           roundTimeout.Round,
+				Policy:          roundTimeout.Policy,
+				PlayersTimedOut: roundTimeout.TimedOutPlayers,
 			},
 		})
 	}
@@ -111,8 +288,10 @@ func PublishGameFinished(gameFinished GameFinished) {
 
 // PublishError publishes the Error event
 func PublishError(subscribers []Subscriber, message string) {
+	seq := nextSeq(subscribers)
 	for _, subscriber := range subscribers {
 		publish(subscriber, model.Event{
+			Seq:  seq,
 			Type: "error",
 			Body: model.Error{
 				Message: message,
@@ -121,38 +300,161 @@ func PublishError(subscribers []Subscriber, message string) {
 	}
 }
 
-func publish(subscriber Subscriber, event model.Event) {
-	go func() {
-		time.Sleep(time.Second)
-		if subscriber.WebsocketConn != nil {
-			publishUsingWebsocket(subscriber.WebsocketConn, event)
-			return
+// PublishMatchFound publishes the MatchFound event, it comes before the game so it has no sequence number
+func PublishMatchFound(matchFound MatchFound) {
+	publish(matchFound.Subscriber, model.Event{
+		Type: "matchFound",
+		Body: model.MatchFound{
+			TicketID: matchFound.TicketID.String(),
+			GameID:   matchFound.GameID.String(),
+			PlayerID: matchFound.PlayerID.String(),
+			Game:     matchFound.GameName,
+			Players:  matchFound.Players,
+			Rounds:   matchFound.Rounds,
+		},
+	})
+}
+
+// PublishTournamentUpdate publishes the TournamentUpdate event
+func PublishTournamentUpdate(tournamentUpdate TournamentUpdate) {
+	standings := ToModelStandings(tournamentUpdate.Standings)
+	tournamentGames := ToModelTournamentGames(tournamentUpdate.Games)
+	subscribers := make([]Subscriber, 0, len(tournamentUpdate.Subscribers))
+	for _, subscriber := range tournamentUpdate.Subscribers {
+		subscribers = append(subscribers, subscriber.Subscriber)
+	}
+	seq := nextSeq(subscribers)
+	for _, subscriber := range tournamentUpdate.Subscribers {
+		body := model.TournamentUpdate{
+			TournamentID: tournamentUpdate.TournamentID.String(),
+			Status:       tournamentUpdate.Status,
+			Round:        tournamentUpdate.Round,
+			Standings:    standings,
+			Games:        tournamentGames,
 		}
-		publishUsingHTTP(subscriber.Callback.String(), event)
-	}()
+		if subscriber.Game != nil {
+			body.Game = &model.PlayerGame{
+				GameID:   subscriber.Game.GameID.String(),
+				PlayerID: subscriber.Game.PlayerID.String(),
+			}
+		}
+		publish(subscriber.Subscriber, model.Event{
+			Seq:  seq,
+			Type: "tournamentUpdate",
+			Body: body,
+		})
+	}
 }
 
-func publishUsingHTTP(callback string, event interface{}) {
-	body, err := json.Marshal(event)
-	if err != nil {
-		msg := fmt.Sprintf("publishing: could not encode %+v", event)
-		logger.Error(errors.Wrap(err, msg))
+// ToModelStandings converts the standings of a tournament to their representation in the API
+func ToModelStandings(standings []Standing) []model.Standing {
+	modelStandings := make([]model.Standing, 0, len(standings))
+	for _, standing := range standings {
+		modelStandings = append(modelStandings, model.Standing{
+			Rank:       standing.Rank,
+			BotID:      standing.BotID.String(),
+			BotName:    standing.BotName,
+			Points:     standing.Points,
+			Wins:       standing.Wins,
+			Losses:     standing.Losses,
+			Draws:      standing.Draws,
+			Byes:       standing.Byes,
+			Eliminated: standing.Eliminated,
+		})
 	}
-	resp, err := http.Post(callback, "application/json", bytes.NewReader(body))
-	if err != nil {
-		logger.Error(errors.Wrap(err, "publishing through HTTP failed"))
-		return
+	return modelStandings
+}
+
+// ToModelTournamentGames converts the games of a tournament to their representation in the API
+func ToModelTournamentGames(tournamentGames []TournamentGame) []model.TournamentGame {
+	modelGames := make([]model.TournamentGame, 0, len(tournamentGames))
+	for _, tournamentGame := range tournamentGames {
+		modelGame := model.TournamentGame{
+			Round:   tournamentGame.Round,
+			Players: tournamentGame.Players,
+			Status:  tournamentGame.Status,
+			Winner:  tournamentGame.Winner,
+		}
+		if tournamentGame.GameID != uuid.Nil {
+			modelGame.GameID = tournamentGame.GameID.String()
+		}
+		modelGames = append(modelGames, modelGame)
 	}
-	if resp.StatusCode != 204 {
-		msg := fmt.Sprintf("expecting status code 204 (No content) but got %d", resp.StatusCode)
-		logger.Warning(errors.New("publishing: " + msg))
+	return modelGames
+}
+
+func toModelScores(scores []Score) []model.Score {
+	modelScores := make([]model.Score, 0, len(scores))
+	for _, score := range scores {
+		modelScore := model.Score{
+			PlayerName: score.PlayerName,
+			PlayerID:   score.PlayerID.String(),
+			Score:      score.Score,
+			Rank:       score.Rank,
+		}
+		if score.BotID != uuid.Nil {
+			modelScore.BotID = score.BotID.String()
+		}
+		modelScores = append(modelScores, modelScore)
 	}
+	return modelScores
 }
 
-func publishUsingWebsocket(conn *websocket.Conn, event interface{}) {
-	err := conn.WriteJSON(event)
-	if err != nil {
-		logger.Error(errors.Wrap(err, "publishing through websocket failed"))
+// Replay sends the given events again through the transport, after the events already queued for it,
+// the events are not added to the log again
+func Replay(transport Transport, events []model.Event) {
+	for _, event := range events {
+		enqueue(transport, event)
+	}
+}
+
+// nextSeq numbers an event sent to the given subscribers, who all play the same game,
+// it is 0 when the game keeps no log
+func nextSeq(subscribers []Subscriber) int {
+	for _, subscriber := range subscribers {
+		if subscriber.Log != nil {
+			return subscriber.Log.next()
+		}
+	}
+	return 0
+}
+
+// nextSeq numbers an event sent to the given subscribers and to the spectators,
+// the spectators can be the only ones getting it
+func (s Spectators) nextSeq(subscribers []Subscriber) int {
+	if seq := nextSeq(subscribers); seq != 0 {
+		return seq
+	}
+	if s.Log != nil {
+		return s.Log.next()
+	}
+	return 0
+}
+
+func (s Spectators) publish(event model.Event) {
+	if s.Log != nil {
+		s.Log.append(SpectatorID, event)
+	}
+	for _, transport := range s.Transports {
+		enqueue(transport, event)
+	}
+}
+
+func resultSubscribers(playerResults []PlayerResult) []Subscriber {
+	subscribers := make([]Subscriber, 0, len(playerResults))
+	for _, playerResult := range playerResults {
+		subscribers = append(subscribers, playerResult.Subscriber)
+	}
+	return subscribers
+}
+
+func publish(subscriber Subscriber, event model.Event) {
+	if subscriber.Log != nil {
+		subscriber.Log.append(subscriber.PlayerID, event)
+	}
+	if subscriber.Transport == nil {
+		logger.Infof("Event %s with seq %d is only kept in the log, the subscriber polls or reconnects for it", event.Type, event.Seq)
 		return
 	}
+	enqueue(subscriber.Transport, event)
 }
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"botServer/web/model"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// receiveWait is how long a test waits for an event to arrive through a channel transport
+const receiveWait = 5 * time.Second
+
+func TestMain(m *testing.M) {
+	logger.Init("Bot Server test", false, false, ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+// TestEventsStayInOrderPerTransport publishes more events than the transports can buffer,
+// every transport has to get them in the order they were published
+func TestEventsStayInOrderPerTransport(t *testing.T) {
+	const numberOfEvents = 100
+	gameID := uuid.New()
+	log := NewGameLog(gameID, 0)
+	var subscribers []Subscriber
+	var transports []*ChannelTransport
+	for i := 0; i < 2; i++ {
+		transport := NewChannelTransport(1)
+		subscriber := Subscriber{Transport: transport, Log: log, PlayerID: uuid.New()}
+		log.AddPlayer(subscriber.PlayerID)
+		subscribers = append(subscribers, subscriber)
+		transports = append(transports, transport)
+	}
+	for round := 1; round <= numberOfEvents; round++ {
+		PublishYourTurn(YourTurn{GameID: gameID, Round: round, Subscribers: subscribers})
+	}
+	for i, transport := range transports {
+		for j, event := range receive(t, transport, numberOfEvents) {
+			if event.Seq != j+1 || event.Body.(model.YourTurn).Round != j+1 {
+				t.Fatalf("transport %d got seq %d of round %d as event %d", i, event.Seq, event.Body.(model.YourTurn).Round, j+1)
+			}
+		}
+	}
+}
+
+// TestSeqNumbersEveryEventOfGame checks that the players and the spectators of a game share the numbering,
+// a player who does not get an event sees a gap in the numbers
+func TestSeqNumbersEveryEventOfGame(t *testing.T) {
+	gameID := uuid.New()
+	log := NewGameLog(gameID, 0)
+	first := Subscriber{Transport: NewChannelTransport(8), Log: log, PlayerID: uuid.New()}
+	second := Subscriber{Transport: NewChannelTransport(8), Log: log, PlayerID: uuid.New()}
+	spectator := NewChannelTransport(8)
+	spectators := Spectators{Log: log, Transports: []Transport{spectator}}
+
+	PublishStartGame(StartGame{GameID: gameID, NextRound: 1, Players: []string{"a", "b"}, Subscribers: []Subscriber{first, second}, Spectators: spectators})
+	PublishYourTurn(YourTurn{GameID: gameID, Round: 1, Subscribers: []Subscriber{first}})
+	PublishRoundFinished(RoundFinished{
+		GameID:        gameID,
+		CurrentRound:  1,
+		NextRound:     2,
+		PlayerResults: []PlayerResult{{Status: "win", Subscriber: first}, {Status: "lose", Subscriber: second}},
+		Winner:        "a",
+		Moves:         map[string]interface{}{"a": "rock", "b": "scissors"},
+		Status:        "win",
+		Spectators:    spectators,
+	})
+
+	assertSeqs(t, "first player", receive(t, first.Transport.(*ChannelTransport), 3), 1, 2, 3)
+	assertSeqs(t, "second player", receive(t, second.Transport.(*ChannelTransport), 2), 1, 3)
+	spectatorEvents := receive(t, spectator, 2)
+	assertSeqs(t, "spectator", spectatorEvents, 1, 3)
+	if status := spectatorEvents[1].Body.(model.RoundFinished).RoundResult.Status; status != "win" {
+		t.Errorf("spectator got round status %s, want win", status)
+	}
+	if got := log.LastSeq(); got != 3 {
+		t.Errorf("last seq is %d, want 3", got)
+	}
+}
+
+// TestReplayQueuesAfterPendingEvents replays events to a transport that did not read the published ones yet,
+// the replayed events have to arrive after them
+func TestReplayQueuesAfterPendingEvents(t *testing.T) {
+	gameID := uuid.New()
+	log := NewGameLog(gameID, 0)
+	transport := NewChannelTransport(0)
+	subscriber := Subscriber{Transport: transport, Log: log, PlayerID: uuid.New()}
+	for round := 1; round <= 3; round++ {
+		PublishYourTurn(YourTurn{GameID: gameID, Round: round, Subscribers: []Subscriber{subscriber}})
+	}
+	Replay(transport, log.Since(subscriber.PlayerID, 1))
+	assertSeqs(t, "transport", receive(t, transport, 5), 1, 2, 3, 2, 3)
+}
+
+// receive reads the given number of events from the transport
+func receive(t *testing.T, transport *ChannelTransport, n int) []model.Event {
+	t.Helper()
+	timer := time.NewTimer(receiveWait)
+	defer timer.Stop()
+	var result []model.Event
+	for len(result) < n {
+		select {
+		case event := <-transport.Events():
+			result = append(result, event)
+		case <-timer.C:
+			t.Fatalf("got %d events in %s, want %d", len(result), receiveWait, n)
+		}
+	}
+	return result
+}
+
+func assertSeqs(t *testing.T, name string, events []model.Event, seqs ...int) {
+	t.Helper()
+	if len(events) != len(seqs) {
+		t.Fatalf("%s got %d events, want %d", name, len(events), len(seqs))
+	}
+	for i, event := range events {
+		if event.Seq != seqs[i] {
+			t.Fatalf("%s got seq %d as event %d, want %d", name, event.Seq, i+1, seqs[i])
+		}
+	}
+}
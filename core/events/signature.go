@@ -0,0 +1,36 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// eventIDHeader identifies the event, it is the same for every attempt to deliver it
+	eventIDHeader = "X-Bot-Event-Id"
+	// timestampHeader has the unix time in seconds when the request was signed
+	timestampHeader = "X-Bot-Timestamp"
+	// signatureHeader has the HMAC-SHA256 of "<timestamp>.<event id>.<body>", hex encoded, with the prefix "sha256="
+	signatureHeader = "X-Bot-Signature"
+)
+
+// sign adds the headers that let the subscriber verify that the event comes from the server,
+// and reject the events that are too old, the event id header is sent even when the events are not signed
+func sign(req *http.Request, body []byte, eventID uuid.UUID, signingSecret string) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(signatureHeader, "sha256="+signature(signingSecret, timestamp, eventID.String(), body))
+}
+
+// signature computes the HMAC-SHA256 of the timestamp, the event id and the body, joined by dots
+func signature(signingSecret, timestamp, eventID string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte(timestamp + "." + eventID + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
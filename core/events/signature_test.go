@@ -0,0 +1,72 @@
+package events
+
+import (
+	"botServer/web/model"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestSignatureKnownAnswer pins the signed input to "<timestamp>.<event id>.<body>",
+// the expected value was computed with another HMAC-SHA256 implementation
+func TestSignatureKnownAnswer(t *testing.T) {
+	got := signature("secret", "1700000000", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", []byte(`{"type":"yourTurn"}`))
+	want := "4bfaf701204900edb705d69748b20697aceffcc13bde61cf850408011e79fd44"
+	if got != want {
+		t.Errorf("got signature %s, want %s", got, want)
+	}
+}
+
+// TestEventHeaders checks the names of the headers sent with an event,
+// the event id is always sent, the timestamp and the signature only when the events are signed
+func TestEventHeaders(t *testing.T) {
+	tests := []struct {
+		name          string
+		signingSecret string
+	}{
+		{name: "signed", signingSecret: "secret"},
+		{name: "not signed"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers := make(chan http.Header, 1)
+			bodies := make(chan []byte, 1)
+			callbacks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+				}
+				headers <- r.Header
+				bodies <- body
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer callbacks.Close()
+			callback, err := url.Parse(callbacks.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := NewHTTPTransport(callback, test.signingSecret).Send(model.Event{Type: "yourTurn"}); err != nil {
+				t.Fatal(err)
+			}
+			header, body := <-headers, <-bodies
+			eventID := header.Get("X-Bot-Event-Id")
+			if _, err := uuid.Parse(eventID); err != nil {
+				t.Errorf("X-Bot-Event-Id is %q, want a uuid", eventID)
+			}
+			timestamp, signed := header.Get("X-Bot-Timestamp"), header.Get("X-Bot-Signature")
+			if test.signingSecret == "" {
+				if timestamp != "" || signed != "" {
+					t.Errorf("the event is not signed but has timestamp %q and signature %q", timestamp, signed)
+				}
+				return
+			}
+			if want := "sha256=" + signature(test.signingSecret, timestamp, eventID, body); timestamp == "" || signed != want {
+				t.Errorf("got timestamp %q and signature %q, want signature %q", timestamp, signed, want)
+			}
+		})
+	}
+}
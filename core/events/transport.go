@@ -0,0 +1,17 @@
+package events
+
+import "botServer/web/model"
+
+// Event is a notification sent to the clients, the events package is where it gets its API representation
+type Event = model.Event
+
+// Transport delivers the events of a subscriber, Send is called for one event at a time,
+// in the order the events were published
+type Transport interface {
+	// Send delivers the event, an error means it was not delivered
+	Send(event model.Event) error
+	// Close stops the transport, the reason is given to the other side if the transport supports it
+	Close(reason string) error
+	// Healthy tells whether events can be delivered through the transport
+	Healthy() bool
+}
@@ -0,0 +1,233 @@
+package core
+
+import (
+	"botServer/core/events"
+	"botServer/core/games"
+	"encoding/json"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const gameFileExtension = ".json"
+
+// fileStore keeps the games in memory and writes every change to a file per game,
+// so that games can be resumed after a restart
+type fileStore struct {
+	*memoryStore
+	dir       string
+	writeLock sync.Mutex
+}
+
+type gameRecord struct {
+	ID              uuid.UUID       `json:"id"`
+	Token           string          `json:"token"`
+	Name            string          `json:"name"`
+	NumberOfPlayers int             `json:"numberOfPlayers"`
+	CurrentRound    int             `json:"currentRound"`
+	TotalRounds     int             `json:"totalRounds"`
+	MoveTimeout     time.Duration   `json:"moveTimeout,omitempty"`
+	TimeoutPolicy   TimeoutPolicy   `json:"timeoutPolicy,omitempty"`
+	Options         games.Options   `json:"options,omitempty"`
+	LastSeq         int             `json:"lastSeq,omitempty"`
+	Players         []playerRecord  `json:"players"`
+	State           json.RawMessage `json:"state,omitempty"`
+}
+
+type playerRecord struct {
+	ID            uuid.UUID   `json:"id"`
+	BotID         uuid.UUID   `json:"botId"`
+	Name          string      `json:"name"`
+	EventCallback string      `json:"eventCallback,omitempty"`
+	LegacyScore   bool        `json:"legacyScore,omitempty"`
+	Polling       bool        `json:"polling,omitempty"`
+	SigningSecret string      `json:"signingSecret"`
+	Token         string      `json:"token"`
+	CurrentMove   interface{} `json:"currentMove,omitempty"`
+	Score         int         `json:"score"`
+}
+
+// NewFileStore creates a game store that persists the games in the given directory,
+// loading the games that were saved there previously
+func NewFileStore(dir string) (GameStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "could not create store directory")
+	}
+	s := &fileStore{memoryStore: newMemoryStore(), dir: dir}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read store directory")
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), gameFileExtension) {
+			continue
+		}
+		g, err := s.load(filepath.Join(dir, file.Name()))
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "skipping stored game %s", file.Name()))
+			continue
+		}
+		s.tokenToGameID[g.token] = g.id
+		s.gameIDToGame[g.id] = g
+	}
+	logger.Infof("Loaded %d games from %s", len(s.gameIDToGame), dir)
+	return s, nil
+}
+
+func (s *fileStore) Add(g *game) (*game, error) {
+	added, err := s.memoryStore.Add(g)
+	if err != nil || added != g {
+		return added, err
+	}
+	if err := s.save(g); err != nil {
+		_ = s.memoryStore.Remove(g.id)
+		return nil, err
+	}
+	return g, nil
+}
+
+func (s *fileStore) Update(g *game) error {
+	if err := s.memoryStore.Update(g); err != nil {
+		return err
+	}
+	return s.save(g)
+}
+
+func (s *fileStore) Remove(id uuid.UUID) error {
+	if err := s.memoryStore.Remove(id); err != nil {
+		return err
+	}
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	err := os.Remove(s.path(id))
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "could not remove stored game")
+	}
+	return nil
+}
+
+func (s *fileStore) path(id uuid.UUID) string {
+	return filepath.Join(s.dir, id.String()+gameFileExtension)
+}
+
+func (s *fileStore) save(g *game) error {
+	record, err := newGameRecord(g)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "could not encode game")
+	}
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	tmp := s.path(g.id) + ".tmp"
+	if err := ioutil.WriteFile(tmp, body, 0644); err != nil {
+		return errors.Wrap(err, "could not write game")
+	}
+	return errors.Wrap(os.Rename(tmp, s.path(g.id)), "could not write game")
+}
+
+func (s *fileStore) load(path string) (*game, error) {
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read game")
+	}
+	var record gameRecord
+	if err := json.Unmarshal(body, &record); err != nil {
+		return nil, errors.Wrap(err, "could not decode game")
+	}
+	return record.toGame()
+}
+
+func newGameRecord(g *game) (gameRecord, error) {
+	state, err := json.Marshal(g.state)
+	if err != nil {
+		return gameRecord{}, errors.Wrap(err, "could not encode game state")
+	}
+	record := gameRecord{
+		ID:              g.id,
+		Token:           g.token,
+		Name:            g.name,
+		NumberOfPlayers: g.numberOfPlayers,
+		CurrentRound:    g.currentRound,
+		TotalRounds:     g.totalRounds,
+		MoveTimeout:     g.moveTimeout,
+		TimeoutPolicy:   g.timeoutPolicy,
+		Options:         g.options,
+		LastSeq:         g.eventLog.LastSeq(),
+		State:           state,
+	}
+	for _, id := range g.playerOrder {
+		p := g.players[id]
+		var callback string
+		if p.EventCallback != nil {
+			callback = p.EventCallback.String()
+		}
+		record.Players = append(record.Players, playerRecord{
+			ID:            p.ID,
+			BotID:         p.BotID,
+			Name:          p.Name,
+			EventCallback: callback,
+			LegacyScore:   p.LegacyScore,
+			Polling:       p.Polling,
+			SigningSecret: p.SigningSecret,
+			Token:         p.Token,
+			CurrentMove:   p.currentMove,
+			Score:         p.score,
+		})
+	}
+	return record, nil
+}
+
+func (r gameRecord) toGame() (*game, error) {
+	gameType, err := games.NewGame(r.Name)
+	if err != nil {
+		return nil, err
+	}
+	g := newGame(r.ID, r.Token, r.Name, gameType, r.NumberOfPlayers, r.TotalRounds)
+	g.currentRound = r.CurrentRound
+	g.moveTimeout = r.MoveTimeout
+	g.timeoutPolicy = r.TimeoutPolicy
+	g.options = r.Options
+	// the events sent before the restart are lost, but the new ones keep the numbering
+	g.eventLog = events.NewGameLog(g.id, r.LastSeq)
+	for _, p := range r.Players {
+		callback, err := url.Parse(p.EventCallback)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid event callback")
+		}
+		g.playerOrder = append(g.playerOrder, p.ID)
+		g.players[p.ID] = &Player{
+			ID:            p.ID,
+			BotID:         p.BotID,
+			Name:          p.Name,
+			EventCallback: callback,
+			LegacyScore:   p.LegacyScore,
+			Polling:       p.Polling,
+			SigningSecret: p.SigningSecret,
+			Token:         p.Token,
+			currentMove:   p.CurrentMove,
+			score:         p.Score,
+			callback:      newCallbackTransport(callback, p.SigningSecret),
+		}
+		g.eventLog.AddPlayer(p.ID)
+		registerToken(g.id, g.players[p.ID])
+	}
+	if g.currentRound > 0 {
+		g.state = gameType.NewState(g.playerOrder, g.options)
+		if g.state != nil && len(r.State) > 0 {
+			if err := json.Unmarshal(r.State, g.state); err != nil {
+				return nil, errors.Wrap(err, "could not decode game state")
+			}
+		}
+	}
+	return g, nil
+}
@@ -0,0 +1,303 @@
+package core
+
+import (
+	"botServer/core/events"
+	"botServer/core/games"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+const (
+	startAttempts = 10
+	// startDelay gives the last player who joined time to get the hello response before the game starts
+	startDelay = time.Second
+)
+
+// game is owned by a single goroutine, every read or change of its state
+// has to happen through a command sent to that goroutine
+type game struct {
+	id               uuid.UUID
+	token            string
+	name             string
+	gameType         games.GameType
+	state            games.State
+	options          games.Options
+	numberOfPlayers  int
+	players          map[uuid.UUID]*Player
+	playerOrder      []uuid.UUID
+	currentRound     int
+	totalRounds      int
+	lastCleanupRound int
+	moveTimeout      time.Duration
+	timeoutPolicy    TimeoutPolicy
+	roundTimer       *time.Timer
+	roundTimerID     int
+	// pausedBy has the players whose websocket was lost, with the id of their grace timer
+	pausedBy     map[uuid.UUID]int
+	graceTimerID int
+	// finished is set once the result of the game was published
+	finished bool
+	// eventLog numbers the events of the game and keeps the ones sent to every player
+	eventLog *events.GameLog
+	// spectators are the connections of the clients watching the game, they are not players
+	spectators map[events.Transport]bool
+	commands   chan command
+	done       chan struct{}
+	stopOnce   sync.Once
+}
+
+// command is an operation that is executed by the goroutine owning the game
+type command interface {
+	execute(g *game)
+}
+
+type connectCommand struct {
+	player *Player
+	reply  chan connectReply
+}
+
+type connectReply struct {
+	response ConnectResponse
+	err      error
+}
+
+type moveCommand struct {
+	request PlayRequest
+	reply   chan moveReply
+}
+
+type moveReply struct {
+	response PlayResponse
+	err      error
+}
+
+type registerTransportCommand struct {
+	playerID  uuid.UUID
+	transport events.Transport
+	lastSeq   int
+	reply     chan error
+}
+
+type disconnectTransportCommand struct {
+	playerID  uuid.UUID
+	transport events.Transport
+	reply     chan error
+}
+
+type spectateCommand struct {
+	transport events.Transport
+	lastSeq   int
+	reply     chan error
+}
+
+type stopSpectatingCommand struct {
+	transport events.Transport
+}
+
+type graceExpiredCommand struct {
+	playerID uuid.UUID
+	timerID  int
+}
+
+type leaveCommand struct {
+	playerID uuid.UUID
+	reply    chan error
+}
+
+type startCommand struct {
+	attemptsLeft int
+}
+
+type roundTimeoutCommand struct {
+	timerID int
+}
+
+type cleanupCommand struct {
+	reply chan bool
+}
+
+func newGame(id uuid.UUID, token, name string, gameType games.GameType, numberOfPlayers, totalRounds int) *game {
+	return &game{
+		id:               id,
+		token:            token,
+		name:             name,
+		gameType:         gameType,
+		numberOfPlayers:  numberOfPlayers,
+		players:          make(map[uuid.UUID]*Player),
+		pausedBy:         make(map[uuid.UUID]int),
+		currentRound:     0,
+		totalRounds:      totalRounds,
+		lastCleanupRound: -1,
+		eventLog:         events.NewGameLog(id, 0),
+		spectators:       make(map[events.Transport]bool),
+		commands:         make(chan command),
+		done:             make(chan struct{}),
+	}
+}
+
+func (g *game) start() {
+	go func() {
+		for {
+			select {
+			case cmd := <-g.commands:
+				cmd.execute(g)
+			case <-g.done:
+				return
+			}
+		}
+	}()
+}
+
+func (g *game) stop() {
+	g.stopOnce.Do(func() {
+		close(g.done)
+	})
+}
+
+func (g *game) send(cmd command) error {
+	select {
+	case g.commands <- cmd:
+		return nil
+	case <-g.done:
+		return errors.New("game is already over")
+	}
+}
+
+// sendLater sends the command after the given delay, without blocking the caller
+func (g *game) sendLater(cmd command, delay time.Duration) *time.Timer {
+	return time.AfterFunc(delay, func() {
+		_ = g.send(cmd)
+	})
+}
+
+func (c connectCommand) execute(g *game) {
+	if len(g.players) >= g.numberOfPlayers {
+		err := errors.New("all players are already connected")
+		c.reply <- connectReply{err: err}
+		return
+	}
+	if c.player.BotID != uuid.Nil {
+		for _, p := range g.players {
+			if p.BotID == c.player.BotID {
+				c.reply <- connectReply{err: errors.Errorf("bot %s already plays in this game", p.Name)}
+				return
+			}
+		}
+	}
+	g.players[c.player.ID] = c.player
+	g.playerOrder = append(g.playerOrder, c.player.ID)
+	if err := store.Update(g); err != nil {
+		delete(g.players, c.player.ID)
+		g.playerOrder = g.playerOrder[:len(g.playerOrder)-1]
+		c.reply <- connectReply{err: err}
+		return
+	}
+	g.eventLog.AddPlayer(c.player.ID)
+	registerToken(g.id, c.player)
+	recordReplay(g, replayPlayerJoined, newReplayPlayer(c.player))
+	if len(g.players) == g.numberOfPlayers {
+		g.sendLater(startCommand{attemptsLeft: startAttempts}, startDelay)
+	}
+	c.reply <- connectReply{response: ConnectResponse{GameID: g.id, Player: *c.player, Rounds: g.totalRounds}}
+}
+
+func (c moveCommand) execute(g *game) {
+	response, err := play(g, c.request)
+	c.reply <- moveReply{response: response, err: err}
+}
+
+func (c registerTransportCommand) execute(g *game) {
+	p, ok := g.players[c.playerID]
+	if !ok {
+		c.reply <- errors.New("player id is not correct")
+		return
+	}
+	if p.Transport != nil && p.Transport != c.transport {
+		_ = p.Transport.Close("replaced by a new connection")
+	}
+	p.Transport = c.transport
+	if _, ok := g.pausedBy[p.ID]; ok {
+		resumeGame(g, p)
+	}
+	if c.lastSeq >= 0 {
+		events.Replay(c.transport, g.eventLog.Since(p.ID, c.lastSeq))
+	}
+	c.reply <- nil
+}
+
+func (c disconnectTransportCommand) execute(g *game) {
+	p, ok := g.players[c.playerID]
+	if !ok {
+		c.reply <- errors.New("player id is not correct")
+		return
+	}
+	if p.Transport != c.transport {
+		c.reply <- nil
+		return
+	}
+	p.Transport = nil
+	// a player who still gets the events another way can keep playing
+	if g.currentRound > 0 && !isReachable(p) {
+		pauseGame(g, p)
+	}
+	c.reply <- nil
+}
+
+func (c spectateCommand) execute(g *game) {
+	g.spectators[c.transport] = true
+	events.Replay(c.transport, g.eventLog.Since(events.SpectatorID, c.lastSeq))
+	c.reply <- nil
+}
+
+func (c stopSpectatingCommand) execute(g *game) {
+	delete(g.spectators, c.transport)
+}
+
+func (c graceExpiredCommand) execute(g *game) {
+	timerID, ok := g.pausedBy[c.playerID]
+	if !ok || timerID != c.timerID {
+		return
+	}
+	delete(g.pausedBy, c.playerID)
+	logger.Infof("Player %s did not reconnect to game %s in time", g.players[c.playerID].Name, g.id)
+	leaveGame(g, g.players[c.playerID])
+}
+
+func (c leaveCommand) execute(g *game) {
+	p, ok := g.players[c.playerID]
+	if !ok {
+		c.reply <- errors.New("player id is not correct")
+		return
+	}
+	leaveGame(g, p)
+	c.reply <- nil
+}
+
+func (c startCommand) execute(g *game) {
+	if g.currentRound != 0 || len(g.players) < g.numberOfPlayers {
+		return
+	}
+	tryStartGame(g, c.attemptsLeft)
+}
+
+func (c roundTimeoutCommand) execute(g *game) {
+	if c.timerID != g.roundTimerID || g.roundTimer == nil {
+		return
+	}
+	g.roundTimer = nil
+	timeoutRound(g)
+}
+
+func (c cleanupCommand) execute(g *game) {
+	if g.lastCleanupRound == g.currentRound {
+		recordReplay(g, replayGameAborted, replayAbort{Reason: "nothing happened in the game since the last cleanup"})
+		removeGame(g, "game aborted")
+		c.reply <- true
+		return
+	}
+	g.lastCleanupRound = g.currentRound
+	c.reply <- false
+}
@@ -0,0 +1,317 @@
+package core
+
+import (
+	"botServer/core/events"
+	"botServer/core/games"
+	"encoding/json"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	// concurrentRequests is how many requests of every kind are sent at the same time in a round
+	concurrentRequests = 50
+	testRounds         = 9
+	// roundsToWin is how many rounds a player has to win to win the game
+	roundsToWin = testRounds/2 + 1
+	startWait   = 5 * time.Second
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("Bot Server test", false, false, ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+// inspectCommand runs a function on the goroutine owning the game,
+// so that the tests can read the state of a running game without races
+type inspectCommand struct {
+	inspect func(g *game)
+	done    chan struct{}
+}
+
+func (c inspectCommand) execute(g *game) {
+	c.inspect(g)
+	close(c.done)
+}
+
+// TestConcurrentPlaysFinishEveryRoundOnce sends many moves of both players of a game at the same time,
+// together with players trying to join the full game and registering websockets,
+// every round has to be finished by exactly one of the moves
+func TestConcurrentPlaysFinishEveryRoundOnce(t *testing.T) {
+	callbacks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer callbacks.Close()
+	callback, err := url.Parse(callbacks.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := "concurrent-" + uuid.New().String()
+	var players []ConnectResponse
+	for _, name := range []string{"first", "second"} {
+		response, err := Connect(ConnectRequest{
+			GameName:      "rps",
+			Token:         token,
+			PlayerName:    name,
+			EventCallback: callback,
+			TotalRounds:   testRounds,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		players = append(players, response)
+	}
+	gameID := players[0].GameID
+	g, ok := store.Get(gameID)
+	if !ok {
+		t.Fatal("the game is not in the store")
+	}
+	waitForStart(t, g)
+
+	// the first player always wins, so the game is over once the first player won the majority of the rounds
+	moves := []string{"rock", "scissors"}
+	for round := 1; round <= roundsToWin; round++ {
+		var (
+			wg         sync.WaitGroup
+			lock       sync.Mutex
+			finishings int
+		)
+		for i, p := range players {
+			for j := 0; j < concurrentRequests; j++ {
+				wg.Add(1)
+				go func(p ConnectResponse, round int, move string) {
+					defer wg.Done()
+					response, err := Play(PlayRequest{
+						GameID:   gameID,
+						PlayerID: p.Player.ID,
+						Token:    p.Player.Token,
+						Round:    round,
+						Move:     move,
+					})
+					// the move that finished the round is the only one answered with the next round
+					if err == nil && response.Round == round+1 {
+						lock.Lock()
+						finishings++
+						lock.Unlock()
+					}
+				}(p, round, moves[i])
+			}
+		}
+		if round < roundsToWin {
+			for j := 0; j < concurrentRequests; j++ {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					if _, err := Connect(ConnectRequest{GameName: "rps", Token: token, EventCallback: callback}); err == nil {
+						t.Error("a player joined the full game")
+					}
+				}()
+				go func(p ConnectResponse) {
+					defer wg.Done()
+					if err := RegisterTransport(gameID, p.Player.ID, p.Player.Token, events.NewChannelTransport(concurrentRequests), -1); err != nil {
+						t.Error(err)
+					}
+				}(players[j%len(players)])
+			}
+		}
+		wg.Wait()
+		if finishings != 1 {
+			t.Fatalf("round %d was finished %d times", round, finishings)
+		}
+	}
+
+	if _, err := Play(PlayRequest{GameID: gameID, PlayerID: players[0].Player.ID, Token: players[0].Player.Token, Round: roundsToWin + 1, Move: "rock"}); err == nil {
+		t.Fatal("the game accepted a move after it was over")
+	}
+	// the goroutine of the game has stopped, so its state can be read directly
+	if score := g.players[players[0].Player.ID].score; score != roundsToWin {
+		t.Errorf("the winner scored %d, want %d", score, roundsToWin)
+	}
+	if score := g.players[players[1].Player.ID].score; score != 0 {
+		t.Errorf("the loser scored %d, want 0", score)
+	}
+}
+
+// TestEndGameTimeoutKeepsTheScores lets the leader of a game time out with the end policy,
+// the round is forfeited and the game ends with the scores after it, so the leader still wins
+func TestEndGameTimeoutKeepsTheScores(t *testing.T) {
+	callbacks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer callbacks.Close()
+	callback, err := url.Parse(callbacks.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := "end-" + uuid.New().String()
+	var players []ConnectResponse
+	for _, name := range []string{"first", "second"} {
+		response, err := Connect(ConnectRequest{
+			GameName:      "rps",
+			Token:         token,
+			PlayerName:    name,
+			EventCallback: callback,
+			TotalRounds:   testRounds,
+			MoveTimeout:   200 * time.Millisecond,
+			TimeoutPolicy: string(EndGame),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		players = append(players, response)
+	}
+	gameID := players[0].GameID
+	g, ok := store.Get(gameID)
+	if !ok {
+		t.Fatal("the game is not in the store")
+	}
+	waitForStart(t, g)
+
+	// the first player leads by 3-0, then only the second player moves
+	moves := []string{"rock", "scissors"}
+	for round := 1; round <= 3; round++ {
+		for i, p := range players {
+			_, err := Play(PlayRequest{GameID: gameID, PlayerID: p.Player.ID, Token: p.Player.Token, Round: round, Move: moves[i]})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := Play(PlayRequest{GameID: gameID, PlayerID: players[1].Player.ID, Token: players[1].Player.Token, Round: 4, Move: "paper"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var result replayResult
+	for _, record := range readReplay(t, gameID) {
+		if record.Type == replayGameFinished {
+			if err := json.Unmarshal(record.Body, &result); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if result.Status != string(games.WIN) || result.Winner != "first" {
+		t.Errorf("the game ended with %s and winner %q, want %s and winner %q", result.Status, result.Winner, games.WIN, "first")
+	}
+	scores := make(map[string]int)
+	for _, score := range result.Scores {
+		scores[score.PlayerName] = score.Score
+	}
+	// the second player scores the forfeit point of the last round
+	if scores["first"] != 3 || scores["second"] != 1 {
+		t.Errorf("the game ended with scores %v, want first 3 and second 1", scores)
+	}
+}
+
+// TestFinishedGameClosesPlayerTransports checks that the connection of a player is closed when the game is over,
+// after the gameFinished event was sent through it
+func TestFinishedGameClosesPlayerTransports(t *testing.T) {
+	token := "close-" + uuid.New().String()
+	var players []ConnectResponse
+	for _, name := range []string{"first", "second"} {
+		response, err := Connect(ConnectRequest{GameName: "rps", Token: token, PlayerName: name, Polling: true, TotalRounds: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		players = append(players, response)
+	}
+	gameID := players[0].GameID
+	g, ok := store.Get(gameID)
+	if !ok {
+		t.Fatal("the game is not in the store")
+	}
+	waitForStart(t, g)
+	transport := events.NewChannelTransport(concurrentRequests)
+	if err := RegisterTransport(gameID, players[0].Player.ID, players[0].Player.Token, transport, -1); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, move := range []string{"rock", "scissors"} {
+		if _, err := Play(PlayRequest{GameID: gameID, PlayerID: players[i].Player.ID, Token: players[i].Player.Token, Round: 1, Move: move}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case <-transport.Done():
+	case <-time.After(startWait):
+		t.Fatalf("the transport of the player is still open %s after the game finished", startWait)
+	}
+	var lastEvent string
+	for len(transport.Events()) > 0 {
+		lastEvent = (<-transport.Events()).Type
+	}
+	if lastEvent != "gameFinished" {
+		t.Errorf("the last event before the transport was closed is %q, want %q", lastEvent, "gameFinished")
+	}
+}
+
+// TestLeftGameClosesSpectators lets a player leave a running game, the spectators get gameFinished
+// and then their connections are closed with the reason
+func TestLeftGameClosesSpectators(t *testing.T) {
+	token := "spectators-" + uuid.New().String()
+	var players []ConnectResponse
+	for _, name := range []string{"first", "second"} {
+		response, err := Connect(ConnectRequest{GameName: "rps", Token: token, PlayerName: name, Polling: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		players = append(players, response)
+	}
+	gameID := players[0].GameID
+	g, ok := store.Get(gameID)
+	if !ok {
+		t.Fatal("the game is not in the store")
+	}
+	waitForStart(t, g)
+	spectator := events.NewChannelTransport(concurrentRequests)
+	if err := Spectate(gameID, spectator, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Leave(gameID, players[1].Player.ID, players[1].Player.Token); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-spectator.Done():
+	case <-time.After(startWait):
+		t.Fatalf("the spectator is still connected %s after the game finished", startWait)
+	}
+	if reason := spectator.Reason(); reason != "game finished" {
+		t.Errorf("the spectator was disconnected with reason %q, want %q", reason, "game finished")
+	}
+	var lastEvent string
+	for len(spectator.Events()) > 0 {
+		lastEvent = (<-spectator.Events()).Type
+	}
+	if lastEvent != "gameFinished" {
+		t.Errorf("the last event before the spectator was disconnected is %q, want %q", lastEvent, "gameFinished")
+	}
+}
+
+// waitForStart waits until the game has started its first round
+func waitForStart(t *testing.T, g *game) {
+	t.Helper()
+	deadline := time.Now().Add(startWait)
+	for time.Now().Before(deadline) {
+		var round int
+		cmd := inspectCommand{inspect: func(g *game) { round = g.currentRound }, done: make(chan struct{})}
+		if err := g.send(cmd); err != nil {
+			t.Fatal(err)
+		}
+		<-cmd.done
+		if round != 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("game %s did not start in %s", g.id, startWait)
+}
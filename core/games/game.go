@@ -18,39 +18,104 @@ const (
 	DRAW Status = "draw"
 )
 
-// GameType describes a specific game
+// GameType describes the rules of a specific game, a single instance is shared by every match of the game,
+// the data of a match is kept in its State
 type GameType interface {
 	Validate(int) bool
 	GetDefaultNumberOfPlayers() int
 	GetDefaultNumberOfRounds() int
-	ValidateMove(interface{}) error
-	EvaluateRound(moves []PlayerMove) RoundResult
+	// ValidateOptions checks the game specific settings given when a match is created
+	ValidateOptions(options Options) error
+	// NewState creates the state of a match when it starts,
+	// players are given in the order they joined the game
+	NewState(players []uuid.UUID, options Options) State
+	// PlayersToMove returns the players whose turn it is in the given round
+	PlayersToMove(state State, players []uuid.UUID, round int) []uuid.UUID
+	ValidateMove(state State, player uuid.UUID, move interface{}) error
+	RandomMove(state State, player uuid.UUID) interface{}
+	// EvaluateRound computes the result of the round and the state of the match after it
+	EvaluateRound(state State, moves []PlayerMove) (RoundResult, State)
+	// IsGameOver decides after every finished round whether the match is over
+	IsGameOver(state State, progress Progress) bool
+	// ForfeitPoints is what the players who did not time out score in a round forfeited by the others
+	ForfeitPoints() int
 }
 
-// PlayerMove has the moves associated to a player
+// ForfeitRecorder is implemented by the game types whose state keeps the past rounds,
+// so that the rounds forfeited on a timeout are kept too, as the core scores them without EvaluateRound
+type ForfeitRecorder interface {
+	// RecordForfeit adds the forfeited round to the state, the move is nil for the players who did not move,
+	// it returns the result with the game state to send to the players and the state of the match after the round
+	RecordForfeit(state State, moves []PlayerMove, result RoundResult) (RoundResult, State)
+}
+
+// State holds the data of a single match, like a board or the history of the moves,
+// it needs to be a pointer that can be encoded to and decoded from JSON, so matches survive restarts,
+// stateless games use nil
+type State interface{}
+
+// Options are the game specific settings of a match, as decoded from JSON
+type Options map[string]interface{}
+
+var gameTypes = map[string]GameType{
+	"rps":               &rockPaperScissors{beats: rpsBeats},
+	"rpsls":             &rockPaperScissors{beats: rpslsBeats},
+	"tictactoe":         &ticTacToe{},
+	"prisoners_dilemma": &prisonersDilemma{},
+}
+
+// PlayerMove has the moves associated to a player,
+// the move is nil for players who did not have to move in the round
 type PlayerMove struct {
 	ID   uuid.UUID
 	Move interface{}
 }
 
-// RoundResult represents the result of a round
+// RoundResult represents the result of a round, the players who won the round have the WIN status
 type RoundResult struct {
 	Status        Status
 	PlayerResults []PlayerResult
-	Winner        uuid.UUID
+	// Replay is set when the round needs to be played again
+	Replay bool
+	// GameState is game specific data sent to the players after the round
+	GameState interface{}
 }
 
 // PlayerResult represents the result of a player in the context of a round
 type PlayerResult struct {
 	ID     uuid.UUID
 	Status Status
+	// Points the player got in the round
+	Points int
 }
 
-// NewGame instantiates a concrete game type specified by the name of the game
+// Progress is what the core tracks about a match, it is given to the game type to decide when the match is over
+type Progress struct {
+	// RoundsPlayed does not count the replayed rounds
+	RoundsPlayed int
+	TotalRounds  int
+	// Scores has the sum of the points of every player
+	Scores map[uuid.UUID]int
+}
+
+// NewGame returns the game type specified by the name of the game
 func NewGame(name string) (GameType, error) {
-	switch name {
-	case "rps":
-		return &rockPaperScissors{}, nil
+	gameType, ok := gameTypes[name]
+	if !ok {
+		return nil, errors.New("game name was not provided or does not exist")
+	}
+	return gameType, nil
+}
+
+// intOption returns the option with the given key as an integer, or the default value if it is missing
+func intOption(options Options, key string, defaultValue int) (int, error) {
+	value, ok := options[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	number, ok := value.(float64)
+	if !ok || number != float64(int(number)) {
+		return 0, errors.Errorf("option %s needs to be an integer", key)
 	}
-	return nil, errors.New("game name was not provided or does not exist")
+	return int(number), nil
 }
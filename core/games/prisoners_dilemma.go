@@ -0,0 +1,191 @@
+package games
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"math/rand"
+)
+
+const (
+	prisonersDilemmaNumberOfPlayers = 2
+	prisonersDilemmaNumberOfRounds  = 10
+	cooperate                       = "cooperate"
+	defect                          = "defect"
+)
+
+// the classic payoffs, where temptation > reward > punishment > sucker
+var defaultPayoffMatrix = payoffMatrix{
+	Reward:     3,
+	Temptation: 5,
+	Sucker:     0,
+	Punishment: 1,
+}
+
+// prisonersDilemma is the iterated prisoner's dilemma, in every round both players
+// cooperate or defect at once and get the points from the payoff matrix, rounds have no winner
+type prisonersDilemma struct{}
+
+// payoffMatrix holds the points a player gets depending on its own and the other player's move
+type payoffMatrix struct {
+	// both players cooperate
+	Reward int `json:"reward"`
+	// the player defects while the other cooperates
+	Temptation int `json:"temptation"`
+	// the player cooperates while the other defects
+	Sucker int `json:"sucker"`
+	// both players defect
+	Punishment int `json:"punishment"`
+}
+
+type prisonersDilemmaState struct {
+	Payoff  payoffMatrix            `json:"payoff"`
+	History []prisonersDilemmaRound `json:"history"`
+}
+
+// prisonersDilemmaRound holds the moves and points of a round, in the order the players joined the game,
+// the move of a player who forfeited the round on a timeout is empty
+type prisonersDilemmaRound struct {
+	Moves  []string `json:"moves"`
+	Points []int    `json:"points"`
+}
+
+// Validate verifies if the given number of players is valid
+func (pd *prisonersDilemma) Validate(noOfPlayers int) bool {
+	return noOfPlayers == prisonersDilemmaNumberOfPlayers
+}
+
+// GetDefaultNumberOfPlayers returns the default number of players
+func (pd *prisonersDilemma) GetDefaultNumberOfPlayers() int {
+	return prisonersDilemmaNumberOfPlayers
+}
+
+// GetDefaultNumberOfRounds returns the default number of rounds
+func (pd *prisonersDilemma) GetDefaultNumberOfRounds() int {
+	return prisonersDilemmaNumberOfRounds
+}
+
+// ValidateOptions checks that the payoffs given in the options are integers
+func (pd *prisonersDilemma) ValidateOptions(options Options) error {
+	_, err := newPayoffMatrix(options)
+	return err
+}
+
+// NewState creates an empty history with the payoff matrix from the options
+func (pd *prisonersDilemma) NewState(players []uuid.UUID, options Options) State {
+	payoff, err := newPayoffMatrix(options)
+	if err != nil {
+		payoff = defaultPayoffMatrix
+	}
+	return &prisonersDilemmaState{Payoff: payoff, History: make([]prisonersDilemmaRound, 0)}
+}
+
+// PlayersToMove returns all the players, as both players move in every round
+func (pd *prisonersDilemma) PlayersToMove(state State, players []uuid.UUID, round int) []uuid.UUID {
+	return players
+}
+
+// ValidateMove checks if the given move is valid
+func (pd *prisonersDilemma) ValidateMove(state State, player uuid.UUID, move interface{}) error {
+	if s, ok := move.(string); !ok || (s != cooperate && s != defect) {
+		return errors.New("Move needs to be one of the values: " + cooperate + "," + defect)
+	}
+	return nil
+}
+
+// RandomMove cooperates or defects at random
+func (pd *prisonersDilemma) RandomMove(state State, player uuid.UUID) interface{} {
+	if rand.Intn(2) == 0 {
+		return cooperate
+	}
+	return defect
+}
+
+// EvaluateRound gives the players their payoffs, and adds the round to the history
+func (pd *prisonersDilemma) EvaluateRound(state State, moves []PlayerMove) (RoundResult, State) {
+	current := state.(*prisonersDilemmaState)
+	round := prisonersDilemmaRound{
+		Moves:  make([]string, len(moves)),
+		Points: make([]int, len(moves)),
+	}
+	for i, move := range moves {
+		round.Moves[i] = move.Move.(string)
+	}
+	result := RoundResult{Status: DRAW}
+	for i, move := range moves {
+		// Validate only allows two players, so the other player is at 1-i
+		round.Points[i] = current.Payoff.points(round.Moves[i], round.Moves[1-i])
+		result.PlayerResults = append(result.PlayerResults, PlayerResult{ID: move.ID, Status: DRAW, Points: round.Points[i]})
+	}
+	return current.addRound(round, result)
+}
+
+// RecordForfeit adds the round forfeited on a timeout to the history, with the forfeit points from the result
+func (pd *prisonersDilemma) RecordForfeit(state State, moves []PlayerMove, result RoundResult) (RoundResult, State) {
+	current := state.(*prisonersDilemmaState)
+	round := prisonersDilemmaRound{
+		Moves:  make([]string, len(moves)),
+		Points: make([]int, len(moves)),
+	}
+	for i, move := range moves {
+		if s, ok := move.Move.(string); ok {
+			round.Moves[i] = s
+		}
+		for _, playerResult := range result.PlayerResults {
+			if playerResult.ID == move.ID {
+				round.Points[i] = playerResult.Points
+			}
+		}
+	}
+	return current.addRound(round, result)
+}
+
+// IsGameOver ends the match when all the rounds were played
+func (pd *prisonersDilemma) IsGameOver(state State, progress Progress) bool {
+	return progress.RoundsPlayed >= progress.TotalRounds
+}
+
+// ForfeitPoints gives the player who moved a single point for a round forfeited by the other
+func (pd *prisonersDilemma) ForfeitPoints() int {
+	return 1
+}
+
+// addRound returns the result with the state after the round, the state of the previous round is not changed
+func (s *prisonersDilemmaState) addRound(round prisonersDilemmaRound, result RoundResult) (RoundResult, State) {
+	next := &prisonersDilemmaState{
+		Payoff:  s.Payoff,
+		History: append(append([]prisonersDilemmaRound(nil), s.History...), round),
+	}
+	result.GameState = next
+	return result, next
+}
+
+func (m payoffMatrix) points(move, otherMove string) int {
+	switch {
+	case move == cooperate && otherMove == cooperate:
+		return m.Reward
+	case move == defect && otherMove == cooperate:
+		return m.Temptation
+	case move == cooperate && otherMove == defect:
+		return m.Sucker
+	default:
+		return m.Punishment
+	}
+}
+
+func newPayoffMatrix(options Options) (payoffMatrix, error) {
+	var err error
+	payoff := defaultPayoffMatrix
+	if payoff.Reward, err = intOption(options, "reward", payoff.Reward); err != nil {
+		return payoffMatrix{}, err
+	}
+	if payoff.Temptation, err = intOption(options, "temptation", payoff.Temptation); err != nil {
+		return payoffMatrix{}, err
+	}
+	if payoff.Sucker, err = intOption(options, "sucker", payoff.Sucker); err != nil {
+		return payoffMatrix{}, err
+	}
+	if payoff.Punishment, err = intOption(options, "punishment", payoff.Punishment); err != nil {
+		return payoffMatrix{}, err
+	}
+	return payoff, nil
+}
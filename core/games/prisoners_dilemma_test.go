@@ -0,0 +1,89 @@
+package games
+
+import (
+	"github.com/google/uuid"
+	"testing"
+)
+
+func TestPrisonersDilemmaIsForTwoPlayers(t *testing.T) {
+	gameType, err := NewGame("prisoners_dilemma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for players, valid := range map[int]bool{1: false, 2: true, 3: false, 4: false} {
+		if gameType.Validate(players) != valid {
+			t.Errorf("%d players are accepted: %t, want %t", players, !valid, valid)
+		}
+	}
+}
+
+func TestPrisonersDilemmaPayoffs(t *testing.T) {
+	tests := []struct {
+		moves  []string
+		points []int
+	}{
+		{moves: []string{cooperate, cooperate}, points: []int{3, 3}},
+		{moves: []string{cooperate, defect}, points: []int{0, 5}},
+		{moves: []string{defect, cooperate}, points: []int{5, 0}},
+		{moves: []string{defect, defect}, points: []int{1, 1}},
+	}
+	gameType, err := NewGame("prisoners_dilemma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	players := []uuid.UUID{uuid.New(), uuid.New()}
+	state := gameType.NewState(players, nil)
+	for _, test := range tests {
+		result, next := gameType.EvaluateRound(state, []PlayerMove{
+			{ID: players[0], Move: test.moves[0]},
+			{ID: players[1], Move: test.moves[1]},
+		})
+		for i, playerResult := range result.PlayerResults {
+			if playerResult.ID != players[i] || playerResult.Status != DRAW || playerResult.Points != test.points[i] {
+				t.Errorf("%v: player %d got %s with %d points, want %s with %d points",
+					test.moves, i, playerResult.Status, playerResult.Points, DRAW, test.points[i])
+			}
+		}
+		state = next
+	}
+	if history := state.(*prisonersDilemmaState).History; len(history) != len(tests) {
+		t.Errorf("history has %d rounds, want %d", len(history), len(tests))
+	}
+}
+
+func TestPrisonersDilemmaRecordsForfeitedRounds(t *testing.T) {
+	gameType, err := NewGame("prisoners_dilemma")
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder, ok := gameType.(ForfeitRecorder)
+	if !ok {
+		t.Fatal("the prisoner's dilemma does not record forfeited rounds")
+	}
+	players := []uuid.UUID{uuid.New(), uuid.New()}
+	state := gameType.NewState(players, nil)
+	_, state = gameType.EvaluateRound(state, []PlayerMove{{ID: players[0], Move: defect}, {ID: players[1], Move: cooperate}})
+
+	// the second player timed out
+	result, state := recorder.RecordForfeit(state, []PlayerMove{{ID: players[0], Move: cooperate}, {ID: players[1]}}, RoundResult{
+		Status: WIN,
+		PlayerResults: []PlayerResult{
+			{ID: players[0], Status: WIN, Points: gameType.ForfeitPoints()},
+			{ID: players[1], Status: LOSE},
+		},
+	})
+	history := state.(*prisonersDilemmaState).History
+	if len(history) != 2 {
+		t.Fatalf("history has %d rounds, want 2", len(history))
+	}
+	forfeited := history[1]
+	if forfeited.Moves[0] != cooperate || forfeited.Moves[1] != "" {
+		t.Errorf("forfeited round has moves %q, want %q and no move", forfeited.Moves, cooperate)
+	}
+	if forfeited.Points[0] != gameType.ForfeitPoints() || forfeited.Points[1] != 0 {
+		t.Errorf("forfeited round has points %v, want %d and 0", forfeited.Points, gameType.ForfeitPoints())
+	}
+	if result.GameState != state || result.Status != WIN {
+		t.Error("the result of the forfeited round was not kept with the new state")
+	}
+}
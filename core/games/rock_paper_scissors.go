@@ -1,7 +1,11 @@
 package games
 
 import (
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"math"
+	"math/rand"
+	"sort"
 	"strings"
 )
 
@@ -11,14 +15,34 @@ const (
 )
 
 var (
-	winsAgainst = map[string]string{"rock": "paper", "paper": "scissors", "scissors": "rock"}
+	rpsBeats = map[string][]string{
+		"rock":     {"scissors"},
+		"paper":    {"rock"},
+		"scissors": {"paper"},
+	}
+	rpslsBeats = map[string][]string{
+		"rock":     {"scissors", "lizard"},
+		"paper":    {"rock", "spock"},
+		"scissors": {"paper", "lizard"},
+		"lizard":   {"paper", "spock"},
+		"spock":    {"rock", "scissors"},
+	}
 )
 
-type rockPaperScissors struct{}
+// rockPaperScissors is played by two or more players at once, in every round each player
+// gets a point for every other player whose move they beat, a round without a winner is replayed
+type rockPaperScissors struct {
+	beats map[string][]string
+}
+
+type rockPaperScissorsState struct {
+	// TargetScore ends the match when a player reaches it, not used when 0
+	TargetScore int `json:"targetScore,omitempty"`
+}
 
 // Validate verifies if the given number of players is valid
 func (rps *rockPaperScissors) Validate(noOfPlayers int) bool {
-	return noOfPlayers == defaultNumberOfPlayers
+	return noOfPlayers >= defaultNumberOfPlayers
 }
 
 // GetDefaultNumberOfPlayers returns the default number of players
@@ -31,43 +55,129 @@ func (rps *rockPaperScissors) GetDefaultNumberOfRounds() int {
 	return defaultNumberOfRounds
 }
 
+// ValidateOptions checks that the target score, if given, is a positive integer
+func (rps *rockPaperScissors) ValidateOptions(options Options) error {
+	targetScore, err := intOption(options, "targetScore", 0)
+	if err != nil {
+		return err
+	}
+	if targetScore < 0 {
+		return errors.New("option targetScore cannot be negative")
+	}
+	return nil
+}
+
+// NewState keeps only the target score, as every round is independent from the previous ones
+func (rps *rockPaperScissors) NewState(players []uuid.UUID, options Options) State {
+	targetScore, _ := intOption(options, "targetScore", 0)
+	return &rockPaperScissorsState{TargetScore: targetScore}
+}
+
+// PlayersToMove returns all the players, as everybody moves in every round
+func (rps *rockPaperScissors) PlayersToMove(state State, players []uuid.UUID, round int) []uuid.UUID {
+	return players
+}
+
 // ValidateMove checks if the given move is valid
-func (rps *rockPaperScissors) ValidateMove(move interface{}) error {
-	validMovesString := validMovesString()
+func (rps *rockPaperScissors) ValidateMove(state State, player uuid.UUID, move interface{}) error {
+	validMovesString := strings.Join(rps.validMoves(), ",")
 	s, ok := move.(string)
 	if !ok {
 		return errors.New("Move needs to be a string, one of the values: " + validMovesString)
 	}
-	if _, ok := winsAgainst[s]; !ok {
+	if _, ok := rps.beats[s]; !ok {
 		return errors.New("Move needs to be one of the values: " + validMovesString)
 	}
 	return nil
 }
 
-// EvaluateRound processes the given moves, and computes the result of the round
-func (rps *rockPaperScissors) EvaluateRound(moves []PlayerMove) RoundResult {
-	var playerResults []PlayerResult
-	firstMove := moves[0].Move.(string)
-	secondMove := moves[1].Move.(string)
-	if winsAgainst[firstMove] == secondMove {
-		playerResults = append(playerResults, PlayerResult{ID: moves[0].ID, Status: LOSE})
-		playerResults = append(playerResults, PlayerResult{ID: moves[1].ID, Status: WIN})
-		return RoundResult{Status: WIN, PlayerResults: playerResults, Winner: moves[1].ID}
+// RandomMove returns one of the valid moves chosen at random
+func (rps *rockPaperScissors) RandomMove(state State, player uuid.UUID) interface{} {
+	validMoves := rps.validMoves()
+	return validMoves[rand.Intn(len(validMoves))]
+}
+
+// EvaluateRound processes the given moves, and computes the result of the round,
+// the players with the most points win the round
+func (rps *rockPaperScissors) EvaluateRound(state State, moves []PlayerMove) (RoundResult, State) {
+	points := make([]int, len(moves))
+	maxPoints, minPoints := 0, len(moves)
+	for i, move := range moves {
+		for j, other := range moves {
+			if i != j && rps.wins(move.Move.(string), other.Move.(string)) {
+				points[i]++
+			}
+		}
+		if points[i] > maxPoints {
+			maxPoints = points[i]
+		}
+		if points[i] < minPoints {
+			minPoints = points[i]
+		}
+	}
+	playerResults := make([]PlayerResult, 0, len(moves))
+	if maxPoints == minPoints {
+		for _, move := range moves {
+			playerResults = append(playerResults, PlayerResult{ID: move.ID, Status: DRAW})
+		}
+		return RoundResult{Status: DRAW, PlayerResults: playerResults, Replay: true}, state
+	}
+	for i, move := range moves {
+		status := LOSE
+		if points[i] == maxPoints {
+			status = WIN
+		}
+		playerResults = append(playerResults, PlayerResult{ID: move.ID, Status: status, Points: points[i]})
+	}
+	return RoundResult{Status: WIN, PlayerResults: playerResults}, state
+}
+
+// IsGameOver ends the match when all the rounds were played, when a player reached the target score,
+// or when the leader cannot be caught up in the remaining rounds, for two players this means winning the majority of the rounds
+func (rps *rockPaperScissors) IsGameOver(state State, progress Progress) bool {
+	if progress.RoundsPlayed >= progress.TotalRounds {
+		return true
+	}
+	first, second := leadingScores(progress.Scores)
+	if s, ok := state.(*rockPaperScissorsState); ok && s.TargetScore > 0 && first >= s.TargetScore {
+		return true
 	}
-	if winsAgainst[secondMove] == firstMove {
-		playerResults = append(playerResults, PlayerResult{ID: moves[0].ID, Status: WIN})
-		playerResults = append(playerResults, PlayerResult{ID: moves[1].ID, Status: LOSE})
-		return RoundResult{Status: WIN, PlayerResults: playerResults, Winner: moves[0].ID}
+	maxPointsPerRound := len(progress.Scores) - 1
+	return first > second+maxPointsPerRound*(progress.TotalRounds-progress.RoundsPlayed)
+}
+
+// ForfeitPoints gives a round won by forfeit the points of a round won against a single player
+func (rps *rockPaperScissors) ForfeitPoints() int {
+	return 1
+}
+
+func (rps *rockPaperScissors) wins(move, against string) bool {
+	for _, beaten := range rps.beats[move] {
+		if beaten == against {
+			return true
+		}
+	}
+	return false
+}
+
+func (rps *rockPaperScissors) validMoves() []string {
+	validMoves := make([]string, 0, len(rps.beats))
+	for move := range rps.beats {
+		validMoves = append(validMoves, move)
 	}
-	playerResults = append(playerResults, PlayerResult{ID: moves[0].ID, Status: DRAW})
-	playerResults = append(playerResults, PlayerResult{ID: moves[1].ID, Status: DRAW})
-	return RoundResult{Status: DRAW, PlayerResults: playerResults}
+	sort.Strings(validMoves)
+	return validMoves
 }
 
-func validMovesString() string {
-	s := ""
-	for k := range winsAgainst {
-		s += k + ","
+// leadingScores returns the highest and the second highest score
+func leadingScores(scores map[uuid.UUID]int) (int, int) {
+	first, second := math.MinInt32, math.MinInt32
+	for _, score := range scores {
+		if score > first {
+			first, second = score, first
+		} else if score > second {
+			second = score
+		}
 	}
-	return strings.TrimRight(s, ",")
+	return first, second
 }
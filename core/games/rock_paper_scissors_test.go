@@ -0,0 +1,123 @@
+package games
+
+import (
+	"github.com/google/uuid"
+	"testing"
+)
+
+func TestEvaluateRoundGivesPairwisePoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		game     string
+		moves    []string
+		status   Status
+		replay   bool
+		statuses []Status
+		points   []int
+	}{
+		{
+			name:     "two players",
+			game:     "rps",
+			moves:    []string{"rock", "scissors"},
+			status:   WIN,
+			statuses: []Status{WIN, LOSE},
+			points:   []int{1, 0},
+		},
+		{
+			name:     "two players with the same move",
+			game:     "rps",
+			moves:    []string{"paper", "paper"},
+			status:   DRAW,
+			replay:   true,
+			statuses: []Status{DRAW, DRAW},
+			points:   []int{0, 0},
+		},
+		{
+			name:     "three players beating each other once",
+			game:     "rps",
+			moves:    []string{"rock", "paper", "scissors"},
+			status:   DRAW,
+			replay:   true,
+			statuses: []Status{DRAW, DRAW, DRAW},
+			points:   []int{0, 0, 0},
+		},
+		{
+			name:     "three players with a single winner",
+			game:     "rpsls",
+			moves:    []string{"rock", "scissors", "lizard"},
+			status:   WIN,
+			statuses: []Status{WIN, LOSE, LOSE},
+			points:   []int{2, 1, 0},
+		},
+		{
+			name:     "four players with two winners",
+			game:     "rpsls",
+			moves:    []string{"rock", "paper", "spock", "lizard"},
+			status:   WIN,
+			statuses: []Status{LOSE, WIN, LOSE, WIN},
+			points:   []int{1, 2, 1, 2},
+		},
+		{
+			name:     "five players with every move",
+			game:     "rpsls",
+			moves:    []string{"rock", "paper", "scissors", "lizard", "spock"},
+			status:   DRAW,
+			replay:   true,
+			statuses: []Status{DRAW, DRAW, DRAW, DRAW, DRAW},
+			points:   []int{0, 0, 0, 0, 0},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gameType, err := NewGame(test.game)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var moves []PlayerMove
+			for _, move := range test.moves {
+				moves = append(moves, PlayerMove{ID: uuid.New(), Move: move})
+			}
+			result, _ := gameType.EvaluateRound(nil, moves)
+			if result.Status != test.status || result.Replay != test.replay {
+				t.Errorf("round is %s with replay %t, want %s with replay %t", result.Status, result.Replay, test.status, test.replay)
+			}
+			if len(result.PlayerResults) != len(moves) {
+				t.Fatalf("got %d player results, want %d", len(result.PlayerResults), len(moves))
+			}
+			for i, playerResult := range result.PlayerResults {
+				if playerResult.ID != moves[i].ID {
+					t.Errorf("result %d is of another player", i)
+				}
+				if playerResult.Status != test.statuses[i] || playerResult.Points != test.points[i] {
+					t.Errorf("%s got %s with %d points, want %s with %d points",
+						test.moves[i], playerResult.Status, playerResult.Points, test.statuses[i], test.points[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateMoveAcceptsTheMovesOfTheGame(t *testing.T) {
+	tests := []struct {
+		game  string
+		move  interface{}
+		valid bool
+	}{
+		{game: "rps", move: "rock", valid: true},
+		{game: "rps", move: "spock", valid: false},
+		{game: "rps", move: 1, valid: false},
+		{game: "rpsls", move: "spock", valid: true},
+		{game: "rpsls", move: "lizard", valid: true},
+		{game: "rpsls", move: "well", valid: false},
+	}
+	for _, test := range tests {
+		gameType, err := NewGame(test.game)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = gameType.ValidateMove(nil, uuid.New(), test.move)
+		if (err == nil) != test.valid {
+			t.Errorf("%s move %v is accepted: %t, want %t", test.game, test.move, err == nil, test.valid)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package games
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+const (
+	boardSize                  = 3
+	ticTacToeNumberOfPlayers   = 2
+	ticTacToeMaxNumberOfRounds = boardSize * boardSize
+	ticTacToeMoveFormatHint    = `Move needs to be a string in the format "row,col", with row and col between 0 and 2`
+)
+
+const (
+	firstMark  = "X"
+	secondMark = "O"
+	emptyCell  = ""
+)
+
+// ticTacToe is turn based, every round exactly one player places a mark,
+// the player who joined first starts with X
+type ticTacToe struct{}
+
+type ticTacToeState struct {
+	Board [boardSize][boardSize]string `json:"board"`
+	Marks map[uuid.UUID]string         `json:"marks"`
+}
+
+// ticTacToeView is the part of the state that is sent to the players after every round
+type ticTacToeView struct {
+	Board [][]string `json:"board"`
+}
+
+// Validate verifies if the given number of players is valid
+func (t *ticTacToe) Validate(noOfPlayers int) bool {
+	return noOfPlayers == ticTacToeNumberOfPlayers
+}
+
+// GetDefaultNumberOfPlayers returns the default number of players
+func (t *ticTacToe) GetDefaultNumberOfPlayers() int {
+	return ticTacToeNumberOfPlayers
+}
+
+// GetDefaultNumberOfRounds returns the default number of rounds, enough to fill the board
+func (t *ticTacToe) GetDefaultNumberOfRounds() int {
+	return ticTacToeMaxNumberOfRounds
+}
+
+// ValidateOptions accepts any options, as the game has no settings
+func (t *ticTacToe) ValidateOptions(options Options) error {
+	return nil
+}
+
+// NewState creates an empty board, and assigns X to the first player and O to the second
+func (t *ticTacToe) NewState(players []uuid.UUID, options Options) State {
+	marks := make(map[uuid.UUID]string, len(players))
+	for i, player := range players {
+		if i == 0 {
+			marks[player] = firstMark
+		} else {
+			marks[player] = secondMark
+		}
+	}
+	return &ticTacToeState{Marks: marks}
+}
+
+// PlayersToMove returns the player whose turn it is in the given round
+func (t *ticTacToe) PlayersToMove(state State, players []uuid.UUID, round int) []uuid.UUID {
+	if len(players) == 0 || round < 1 {
+		return nil
+	}
+	return []uuid.UUID{players[(round-1)%len(players)]}
+}
+
+// ValidateMove checks if the given move is well formed and targets an empty cell
+func (t *ticTacToe) ValidateMove(state State, player uuid.UUID, move interface{}) error {
+	row, col, err := parseCell(move)
+	if err != nil {
+		return err
+	}
+	if state.(*ticTacToeState).Board[row][col] != emptyCell {
+		return errors.Errorf("Cell %d,%d is already taken", row, col)
+	}
+	return nil
+}
+
+// RandomMove returns one of the empty cells chosen at random
+func (t *ticTacToe) RandomMove(state State, player uuid.UUID) interface{} {
+	board := state.(*ticTacToeState).Board
+	var emptyCells []string
+	for row := range board {
+		for col := range board[row] {
+			if board[row][col] == emptyCell {
+				emptyCells = append(emptyCells, strconv.Itoa(row)+","+strconv.Itoa(col))
+			}
+		}
+	}
+	if len(emptyCells) == 0 {
+		return nil
+	}
+	return emptyCells[rand.Intn(len(emptyCells))]
+}
+
+// EvaluateRound places the mark of the player who moved, and checks whether the game is over
+func (t *ticTacToe) EvaluateRound(state State, moves []PlayerMove) (RoundResult, State) {
+	next := *state.(*ticTacToeState)
+	var winner uuid.UUID
+	for _, move := range moves {
+		if move.Move == nil {
+			continue
+		}
+		row, col, err := parseCell(move.Move)
+		if err != nil || next.Board[row][col] != emptyCell {
+			continue
+		}
+		mark := next.Marks[move.ID]
+		next.Board[row][col] = mark
+		if next.hasLine(mark) {
+			winner = move.ID
+		}
+	}
+	result := RoundResult{Status: DRAW, GameState: next.view()}
+	if winner != uuid.Nil {
+		result.Status = WIN
+	}
+	for _, move := range moves {
+		playerResult := PlayerResult{ID: move.ID, Status: result.Status}
+		if move.ID == winner {
+			playerResult.Points = 1
+		} else if winner != uuid.Nil {
+			playerResult.Status = LOSE
+		}
+		result.PlayerResults = append(result.PlayerResults, playerResult)
+	}
+	return result, &next
+}
+
+// IsGameOver ends the match when a player has a line, when the board is full, or when all the rounds were played
+func (t *ticTacToe) IsGameOver(state State, progress Progress) bool {
+	s := state.(*ticTacToeState)
+	return s.hasLine(firstMark) || s.hasLine(secondMark) || s.isFull() || progress.RoundsPlayed >= progress.TotalRounds
+}
+
+// ForfeitPoints scores nothing for a forfeited turn, as only a line decides the winner of the match
+func (t *ticTacToe) ForfeitPoints() int {
+	return 0
+}
+
+func (s *ticTacToeState) hasLine(mark string) bool {
+	diagonal, antiDiagonal := true, true
+	for i := 0; i < boardSize; i++ {
+		row, col := true, true
+		for j := 0; j < boardSize; j++ {
+			row = row && s.Board[i][j] == mark
+			col = col && s.Board[j][i] == mark
+		}
+		if row || col {
+			return true
+		}
+		diagonal = diagonal && s.Board[i][i] == mark
+		antiDiagonal = antiDiagonal && s.Board[i][boardSize-1-i] == mark
+	}
+	return diagonal || antiDiagonal
+}
+
+func (s *ticTacToeState) isFull() bool {
+	for row := range s.Board {
+		for col := range s.Board[row] {
+			if s.Board[row][col] == emptyCell {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *ticTacToeState) view() ticTacToeView {
+	board := make([][]string, boardSize)
+	for row := range s.Board {
+		board[row] = append([]string(nil), s.Board[row][:]...)
+	}
+	return ticTacToeView{Board: board}
+}
+
+func parseCell(move interface{}) (int, int, error) {
+	s, ok := move.(string)
+	if !ok {
+		return 0, 0, errors.New(ticTacToeMoveFormatHint)
+	}
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, errors.New(ticTacToeMoveFormatHint)
+	}
+	row, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || row < 0 || row >= boardSize {
+		return 0, 0, errors.New(ticTacToeMoveFormatHint)
+	}
+	col, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || col < 0 || col >= boardSize {
+		return 0, 0, errors.New(ticTacToeMoveFormatHint)
+	}
+	return row, col, nil
+}
@@ -0,0 +1,107 @@
+package games
+
+import (
+	"github.com/google/uuid"
+	"testing"
+)
+
+func TestTicTacToeValidateMove(t *testing.T) {
+	gameType, err := NewGame("tictactoe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	players := []uuid.UUID{uuid.New(), uuid.New()}
+	state := gameType.NewState(players, nil)
+	_, state = gameType.EvaluateRound(state, []PlayerMove{{ID: players[0], Move: "1,1"}, {ID: players[1]}})
+
+	tests := []struct {
+		name  string
+		move  interface{}
+		valid bool
+	}{
+		{name: "empty cell", move: "0,0", valid: true},
+		{name: "last cell", move: "2,2", valid: true},
+		{name: "spaces around the numbers", move: " 0 , 2 ", valid: true},
+		{name: "occupied cell", move: "1,1", valid: false},
+		{name: "row out of range", move: "3,0", valid: false},
+		{name: "column out of range", move: "0,3", valid: false},
+		{name: "negative row", move: "-1,0", valid: false},
+		{name: "letters", move: "a,b", valid: false},
+		{name: "single number", move: "0", valid: false},
+		{name: "three numbers", move: "0,0,0", valid: false},
+		{name: "empty string", move: "", valid: false},
+		{name: "not a string", move: 4, valid: false},
+		{name: "no move", move: nil, valid: false},
+	}
+	for _, test := range tests {
+		err := gameType.ValidateMove(state, players[1], test.move)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: move %v is accepted: %t, want %t", test.name, test.move, err == nil, test.valid)
+		}
+	}
+}
+
+func TestTicTacToeEndsWithLineOrFullBoard(t *testing.T) {
+	// the players take turns, X moves first, winner is the index of the player with the line, -1 if none
+	tests := []struct {
+		name     string
+		moves    []string
+		winner   int
+		gameOver bool
+	}{
+		{name: "row", moves: []string{"0,0", "1,0", "0,1", "1,1", "0,2"}, winner: 0, gameOver: true},
+		{name: "column", moves: []string{"0,1", "0,0", "1,1", "2,2", "2,1"}, winner: 0, gameOver: true},
+		{name: "diagonal", moves: []string{"0,0", "0,1", "1,1", "0,2", "2,2"}, winner: 0, gameOver: true},
+		{name: "anti-diagonal", moves: []string{"0,0", "0,2", "0,1", "1,1", "2,2", "2,0"}, winner: 1, gameOver: true},
+		{name: "no line yet", moves: []string{"0,0", "1,1", "0,1"}, winner: -1, gameOver: false},
+		{
+			name:     "full board without a line",
+			moves:    []string{"0,0", "0,1", "0,2", "1,1", "1,0", "1,2", "2,1", "2,0", "2,2"},
+			winner:   -1,
+			gameOver: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gameType, err := NewGame("tictactoe")
+			if err != nil {
+				t.Fatal(err)
+			}
+			players := []uuid.UUID{uuid.New(), uuid.New()}
+			state := gameType.NewState(players, nil)
+			var result RoundResult
+			for i, move := range test.moves {
+				moves := []PlayerMove{{ID: players[0]}, {ID: players[1]}}
+				moves[i%2].Move = move
+				if err := gameType.ValidateMove(state, players[i%2], move); err != nil {
+					t.Fatalf("move %d: %v", i+1, err)
+				}
+				result, state = gameType.EvaluateRound(state, moves)
+			}
+
+			wantStatus := DRAW
+			if test.winner >= 0 {
+				wantStatus = WIN
+			}
+			if result.Status != wantStatus {
+				t.Errorf("last round is %s, want %s", result.Status, wantStatus)
+			}
+			for i, playerResult := range result.PlayerResults {
+				wantPlayerStatus, wantPoints := DRAW, 0
+				if i == test.winner {
+					wantPlayerStatus, wantPoints = WIN, 1
+				} else if test.winner >= 0 {
+					wantPlayerStatus = LOSE
+				}
+				if playerResult.Status != wantPlayerStatus || playerResult.Points != wantPoints {
+					t.Errorf("player %d got %s with %d points, want %s with %d points",
+						i, playerResult.Status, playerResult.Points, wantPlayerStatus, wantPoints)
+				}
+			}
+			progress := Progress{RoundsPlayed: len(test.moves), TotalRounds: ticTacToeMaxNumberOfRounds}
+			if gameOver := gameType.IsGameOver(state, progress); gameOver != test.gameOver {
+				t.Errorf("game is over: %t, want %t", gameOver, test.gameOver)
+			}
+		})
+	}
+}
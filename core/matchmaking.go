@@ -0,0 +1,360 @@
+package core
+
+import (
+	"botServer/core/events"
+	"botServer/core/games"
+	"context"
+	"crypto/subtle"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// ticketRetention is how long a ticket can still be read after it left the queue
+	ticketRetention = 10 * time.Minute
+	// initialRatingWindow is the largest rating difference of the bots matched as soon as they join the queue
+	initialRatingWindow = 100
+	// ratingWindowGrowth is how much the rating window of a bot widens every second it waits
+	ratingWindowGrowth = 10
+)
+
+// The statuses of a ticket
+const (
+	TicketWaiting   = "waiting"
+	TicketMatched   = "matched"
+	TicketCancelled = "cancelled"
+	TicketFailed    = "failed"
+)
+
+// QueueRequest is the input for the JoinQueue operation in the core
+type QueueRequest struct {
+	GameName      string
+	NoOfPlayers   int
+	PlayerName    string
+	EventCallback *url.URL
+	LegacyScore   bool
+	Polling       bool
+	APIKey        string
+}
+
+// Ticket is the place of a bot in the matchmaking queue
+type Ticket struct {
+	ID              uuid.UUID
+	GameName        string
+	NumberOfPlayers int
+	// Token authenticates the requests about the ticket, the matched player keeps it
+	Token string
+	// SigningSecret signs the matchFound event posted to the event callback, the matched player keeps it
+	SigningSecret string
+	Status        string
+	// Rating is the rating of the bot in the game when it joined the queue, the initial rating for anonymous players
+	Rating float64
+	// Match is set once the ticket is matched
+	Match *Match
+	// Error tells why the bot could not join the game it was matched to
+	Error      string
+	EnqueuedAt time.Time
+}
+
+// Match is the game a ticket was put in
+type Match struct {
+	GameID   uuid.UUID
+	PlayerID uuid.UUID
+	// Players are the names of the players of the game, in the order they were matched
+	Players []string
+	Rounds  int
+}
+
+type queueKey struct {
+	gameName        string
+	numberOfPlayers int
+}
+
+type queuedTicket struct {
+	Ticket
+	botID    uuid.UUID
+	request  ConnectRequest
+	callback *events.HTTPTransport
+	// done is closed when the ticket leaves the queue
+	done chan struct{}
+}
+
+var (
+	queueLock sync.Mutex
+	// queues has the waiting tickets of every game and number of players, oldest first
+	queues  = make(map[queueKey][]*queuedTicket)
+	tickets = make(map[uuid.UUID]*queuedTicket)
+)
+
+// JoinQueue puts the bot in the queue of the game, it is put in a game as soon as enough bots with
+// a close enough rating wait for the same game with the same number of players
+func JoinQueue(req QueueRequest) (Ticket, error) {
+	gameType, err := games.NewGame(req.GameName)
+	if err != nil {
+		return Ticket{}, errors.Wrap(err, "could not join queue")
+	}
+	numberOfPlayers, err := getNumberOfPlayers(gameType, req.NoOfPlayers)
+	if err != nil {
+		return Ticket{}, errors.Wrap(err, "could not join queue")
+	}
+	bot, playerName, err := identifyPlayer(req.APIKey, req.PlayerName)
+	if err != nil {
+		return Ticket{}, errors.Wrap(err, "could not join queue")
+	}
+	t := &queuedTicket{
+		Ticket: Ticket{
+			ID:              uuid.New(),
+			GameName:        req.GameName,
+			NumberOfPlayers: numberOfPlayers,
+			Token:           newSecret(),
+			SigningSecret:   newSecret(),
+			Status:          TicketWaiting,
+			Rating:          botRating(req.GameName, bot.ID),
+			EnqueuedAt:      time.Now().UTC(),
+		},
+		botID: bot.ID,
+		done:  make(chan struct{}),
+	}
+	t.request = ConnectRequest{
+		GameName:      req.GameName,
+		NoOfPlayers:   numberOfPlayers,
+		PlayerName:    playerName,
+		EventCallback: req.EventCallback,
+		LegacyScore:   req.LegacyScore,
+		Polling:       req.Polling,
+		APIKey:        req.APIKey,
+		PlayerToken:   t.Token,
+		SigningSecret: t.SigningSecret,
+	}
+	t.callback = newCallbackTransport(req.EventCallback, t.SigningSecret)
+	key := queueKey{gameName: req.GameName, numberOfPlayers: numberOfPlayers}
+
+	queueLock.Lock()
+	if bot.ID != uuid.Nil && isQueued(bot.ID) {
+		queueLock.Unlock()
+		err := errors.Errorf("bot %s is already waiting in the queue", bot.Name)
+		return Ticket{}, errors.Wrap(err, "could not join queue")
+	}
+	tickets[t.ID] = t
+	queues[key] = append(queues[key], t)
+	queueLock.Unlock()
+	logger.Infof("Ticket %s is waiting for a game of %s with %d players", t.ID, key.gameName, key.numberOfPlayers)
+
+	matchQueue(key)
+	return snapshot(t), nil
+}
+
+// WaitForMatch returns the ticket, waiting at most wait for it to be matched if it is still waiting
+func WaitForMatch(ctx context.Context, ticketID uuid.UUID, token string, wait time.Duration) (Ticket, error) {
+	t, err := authenticateTicket(ticketID, token)
+	if err != nil {
+		return Ticket{}, errors.Wrap(err, "could not get ticket")
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-t.done:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return snapshot(t), nil
+}
+
+// LeaveQueue takes the ticket out of the queue, a matched ticket cannot leave, its game has to be left instead
+func LeaveQueue(ticketID uuid.UUID, token string) error {
+	t, err := authenticateTicket(ticketID, token)
+	if err != nil {
+		return errors.Wrap(err, "could not leave queue")
+	}
+	queueLock.Lock()
+	defer queueLock.Unlock()
+	key := queueKey{gameName: t.GameName, numberOfPlayers: t.NumberOfPlayers}
+	if !removeFromQueue(key, t) {
+		if t.Status == TicketWaiting {
+			return errors.New("could not leave queue: ticket is being matched")
+		}
+		return errors.Errorf("could not leave queue: ticket is %s", t.Status)
+	}
+	finishTicket(t, TicketCancelled)
+	return nil
+}
+
+// StartMatchmaker starts the process that matches the waiting bots whose rating window widened enough
+func StartMatchmaker() {
+	ticker := time.NewTicker(time.Second)
+
+	go func() {
+		for range ticker.C {
+			queueLock.Lock()
+			keys := make([]queueKey, 0, len(queues))
+			for key := range queues {
+				keys = append(keys, key)
+			}
+			queueLock.Unlock()
+			for _, key := range keys {
+				matchQueue(key)
+			}
+		}
+	}()
+}
+
+// matchQueue starts a game for every group of tickets the queue has
+func matchQueue(key queueKey) {
+	for {
+		queueLock.Lock()
+		group := pickMatch(queues[key], key.numberOfPlayers, time.Now())
+		for _, t := range group {
+			removeFromQueue(key, t)
+		}
+		queueLock.Unlock()
+		if group == nil {
+			return
+		}
+		startMatch(key, group)
+	}
+}
+
+// pickMatch chooses the tickets of the next game from the waiting tickets, the ones waiting the longest first,
+// every two bots of a game have to be within the rating window of one of them
+func pickMatch(queue []*queuedTicket, numberOfPlayers int, now time.Time) []*queuedTicket {
+	if len(queue) < numberOfPlayers {
+		return nil
+	}
+	for i, oldest := range queue {
+		group := []*queuedTicket{oldest}
+		for _, t := range queue[i+1:] {
+			if fitsGroup(group, t, now) {
+				group = append(group, t)
+			}
+			if len(group) == numberOfPlayers {
+				return group
+			}
+		}
+	}
+	return nil
+}
+
+func fitsGroup(group []*queuedTicket, t *queuedTicket, now time.Time) bool {
+	for _, member := range group {
+		window := math.Max(ratingWindow(member, now), ratingWindow(t, now))
+		if math.Abs(member.Rating-t.Rating) > window {
+			return false
+		}
+	}
+	return true
+}
+
+// ratingWindow is the largest rating difference the bot accepts, it widens the longer the bot waits
+func ratingWindow(t *queuedTicket, now time.Time) float64 {
+	return initialRatingWindow + ratingWindowGrowth*now.Sub(t.EnqueuedAt).Seconds()
+}
+
+// startMatch connects the tickets to a new game, if one of them cannot join, the others go back to the queue
+func startMatch(key queueKey, group []*queuedTicket) {
+	token := "match-" + uuid.New().String()
+	responses := make([]ConnectResponse, 0, len(group))
+	for i, t := range group {
+		req := t.request
+		req.Token = token
+		response, err := Connect(req)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "ticket %s could not join its match", t.ID))
+			for _, joined := range responses {
+				if err := Leave(joined.GameID, joined.Player.ID, joined.Player.Token); err != nil {
+					logger.Error(err)
+				}
+			}
+			queueLock.Lock()
+			t.Error = err.Error()
+			finishTicket(t, TicketFailed)
+			others := append(append([]*queuedTicket(nil), group[:i]...), group[i+1:]...)
+			queues[key] = append(others, queues[key]...)
+			queueLock.Unlock()
+			return
+		}
+		responses = append(responses, response)
+	}
+
+	players := make([]string, 0, len(responses))
+	for _, response := range responses {
+		players = append(players, response.Player.Name)
+	}
+	queueLock.Lock()
+	defer queueLock.Unlock()
+	for i, t := range group {
+		response := responses[i]
+		t.Match = &Match{GameID: response.GameID, PlayerID: response.Player.ID, Players: players, Rounds: response.Rounds}
+		finishTicket(t, TicketMatched)
+		if t.callback == nil {
+			continue
+		}
+		events.PublishMatchFound(events.MatchFound{
+			TicketID:   t.ID,
+			GameID:     response.GameID,
+			PlayerID:   response.Player.ID,
+			GameName:   t.GameName,
+			Players:    players,
+			Rounds:     response.Rounds,
+			Subscriber: events.Subscriber{Transport: t.callback, PlayerID: response.Player.ID},
+		})
+	}
+	logger.Infof("Matched %d tickets of %s into game %s", len(group), key.gameName, responses[0].GameID)
+}
+
+func authenticateTicket(ticketID uuid.UUID, token string) (*queuedTicket, error) {
+	queueLock.Lock()
+	t, ok := tickets[ticketID]
+	queueLock.Unlock()
+	if !ok || subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) != 1 {
+		return nil, ErrUnauthorized
+	}
+	return t, nil
+}
+
+// isQueued tells whether the bot has a waiting ticket, it has to be called holding queueLock
+func isQueued(botID uuid.UUID) bool {
+	for _, t := range tickets {
+		if t.botID == botID && t.Status == TicketWaiting {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFromQueue tells whether the ticket was waiting in the queue, it has to be called holding queueLock
+func removeFromQueue(key queueKey, t *queuedTicket) bool {
+	queue := queues[key]
+	for i, queued := range queue {
+		if queued == t {
+			queues[key] = append(queue[:i:i], queue[i+1:]...)
+			if len(queues[key]) == 0 {
+				delete(queues, key)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// finishTicket records that the ticket left the queue, it is dropped after ticketRetention,
+// it has to be called holding queueLock
+func finishTicket(t *queuedTicket, status string) {
+	t.Status = status
+	close(t.done)
+	time.AfterFunc(ticketRetention, func() {
+		queueLock.Lock()
+		defer queueLock.Unlock()
+		delete(tickets, t.ID)
+	})
+}
+
+func snapshot(t *queuedTicket) Ticket {
+	queueLock.Lock()
+	defer queueLock.Unlock()
+	return t.Ticket
+}
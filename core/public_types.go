@@ -1,10 +1,27 @@
 package core
 
 import (
+	"botServer/core/events"
+	"crypto/rand"
+	"encoding/hex"
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
 	"net/url"
 	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// TimeoutPolicy decides how a round is resolved when some players do not make their move in time
+type TimeoutPolicy string
+
+const (
+	// ForfeitRound - the players who did not move lose the round
+	ForfeitRound TimeoutPolicy = "forfeit"
+	// PlayRandomMove - a random valid move is played instead of the players who did not move
+	PlayRandomMove TimeoutPolicy = "random"
+	// EndGame - the players who did not move lose the round, and the game ends with the scores after it
+	EndGame TimeoutPolicy = "end"
 )
 
 // ConnectRequest is the input for the Connect operation in the core
@@ -14,7 +31,24 @@ type ConnectRequest struct {
 	NoOfPlayers   int
 	PlayerName    string
 	EventCallback *url.URL
+	// Transport is set when the player connects through a connection, such as a websocket,
+	// events are sent through it from the start instead of the callback
+	Transport     events.Transport
 	TotalRounds   int
+	MoveTimeout   time.Duration
+	TimeoutPolicy string
+	// GameOptions are game specific settings, only used when the game is created
+	GameOptions map[string]interface{}
+	// LegacyScore keeps sending the score as a dash separated string, for players that still rely on it
+	LegacyScore bool
+	// Polling players fetch their events through Events instead of having them pushed
+	Polling bool
+	// APIKey identifies a registered bot, the player gets the name of the bot
+	APIKey string
+	// PlayerToken and SigningSecret are set by the matchmaker, so that the player keeps the ones of its ticket,
+	// new ones are generated when empty
+	PlayerToken   string
+	SigningSecret string
 }
 
 // ConnectResponse is the output for the Connect operation in the core
@@ -28,8 +62,10 @@ type ConnectResponse struct {
 type PlayRequest struct {
 	GameID   uuid.UUID
 	PlayerID uuid.UUID
-	Round    int
-	Move     interface{}
+	// Token authenticates the player
+	Token string
+	Round int
+	Move  interface{}
 }
 
 // PlayResponse is the output for the Play operation in the core
@@ -40,24 +76,65 @@ type PlayResponse struct {
 
 // Player holds data of a player in the context of a Connect operation
 type Player struct {
-	ID            uuid.UUID
+	ID uuid.UUID
+	// BotID is the id of the registered bot playing, uuid.Nil for anonymous players
+	BotID         uuid.UUID
 	Name          string
 	EventCallback *url.URL
-	WebsocketConn *websocket.Conn
-	currentMove   interface{}
-	score         int
+	// Transport is the connection the player is notified through, the callback is used while it is nil
+	Transport   events.Transport
+	LegacyScore bool
+	Polling     bool
+	// SigningSecret is the key of the HMAC signature of the events posted to the event callback
+	SigningSecret string
+	// Token authenticates the requests of the player
+	Token       string
+	currentMove interface{}
+	score       int
+	// callback posts the events to the event callback, nil when it is not an absolute URL
+	callback *events.HTTPTransport
 }
 
 func getOrCreatePlayer(playerName string, eventCallback *url.URL) *Player {
 	if playerName == "" {
-		playerName = "Player" + strconv.FormatInt(playerNameNr, 10)
-		playerNameNr++
+		playerName = "Player" + strconv.FormatInt(atomic.AddInt64(&playerNameNr, 1)-1, 10)
 	}
-	return &Player{
+	p := &Player{
 		ID:            uuid.New(),
 		Name:          playerName,
 		EventCallback: eventCallback,
+		SigningSecret: newSecret(),
+		Token:         newSecret(),
 		score:         0,
 		currentMove:   nil,
 	}
+	p.callback = newCallbackTransport(p.EventCallback, p.SigningSecret)
+	return p
+}
+
+func newCallbackTransport(callback *url.URL, signingSecret string) *events.HTTPTransport {
+	if callback == nil || !callback.IsAbs() {
+		return nil
+	}
+	return events.NewHTTPTransport(callback, signingSecret)
+}
+
+func (p *Player) subscriber(log *events.GameLog) events.Subscriber {
+	subscriber := events.Subscriber{
+		Transport: p.Transport,
+		Log:       log,
+		PlayerID:  p.ID,
+	}
+	if subscriber.Transport == nil && p.callback != nil {
+		subscriber.Transport = p.callback
+	}
+	return subscriber
+}
+
+func newSecret() string {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(errors.Wrap(err, "could not generate secret"))
+	}
+	return hex.EncodeToString(secret)
 }
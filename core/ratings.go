@@ -0,0 +1,235 @@
+package core
+
+import (
+	"botServer/core/games"
+	"encoding/json"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// initialRating is the Elo rating of a bot in its first game of a game type
+	initialRating = 1500
+	// ratingK is the most a rating can change after a game
+	ratingK = 32
+)
+
+// Rating is the Elo rating of a bot in a game type
+type Rating struct {
+	BotID     uuid.UUID
+	BotName   string
+	GameName  string
+	Rating    float64
+	Games     int
+	Wins      int
+	Losses    int
+	Draws     int
+	UpdatedAt time.Time
+	// Rank is the place of the bot on the leaderboard of the game type, bots with the same rating share it
+	Rank int
+}
+
+type ratingRecord struct {
+	BotID     uuid.UUID `json:"botId"`
+	GameName  string    `json:"game"`
+	Rating    float64   `json:"rating"`
+	Games     int       `json:"games"`
+	Wins      int       `json:"wins"`
+	Losses    int       `json:"losses"`
+	Draws     int       `json:"draws"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type ratingKey struct {
+	gameName string
+	botID    uuid.UUID
+}
+
+var (
+	ratingsLock sync.Mutex
+	ratings     = make(map[ratingKey]*ratingRecord)
+	// ratingsFile is where the ratings are saved, they are only kept in memory when it is empty
+	ratingsFile string
+)
+
+// LoadRatings loads the ratings from the given file, and saves them there after every rated game
+func LoadRatings(path string) error {
+	ratingsLock.Lock()
+	defer ratingsLock.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrap(err, "could not create ratings directory")
+	}
+	ratingsFile = path
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not read ratings")
+	}
+	var records []*ratingRecord
+	if err := json.Unmarshal(body, &records); err != nil {
+		return errors.Wrap(err, "could not decode ratings")
+	}
+	for _, record := range records {
+		ratings[ratingKey{gameName: record.GameName, botID: record.BotID}] = record
+	}
+	logger.Infof("Loaded %d ratings from %s", len(records), path)
+	return nil
+}
+
+// Ratings returns the leaderboard of the game type, from the highest rating to the lowest
+func Ratings(gameName string) ([]Rating, error) {
+	if _, err := games.NewGame(gameName); err != nil {
+		return nil, errors.Wrap(err, "could not get ratings")
+	}
+	ratingsLock.Lock()
+	result := make([]Rating, 0)
+	for key, record := range ratings {
+		if key.gameName == gameName {
+			result = append(result, record.rating())
+		}
+	}
+	ratingsLock.Unlock()
+	names := botNames()
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Rating != result[j].Rating {
+			return result[i].Rating > result[j].Rating
+		}
+		return result[i].Games > result[j].Games
+	})
+	for i := range result {
+		result[i].BotName = names[result[i].BotID]
+		if i > 0 && math.Round(result[i].Rating) == math.Round(result[i-1].Rating) {
+			result[i].Rank = result[i-1].Rank
+		} else {
+			result[i].Rank = i + 1
+		}
+	}
+	return result, nil
+}
+
+// botRating returns the rating of the bot in the game type, anonymous players and new bots have the initial rating
+func botRating(gameName string, botID uuid.UUID) float64 {
+	ratingsLock.Lock()
+	defer ratingsLock.Unlock()
+	if record, ok := ratings[ratingKey{gameName: gameName, botID: botID}]; ok {
+		return record.Rating
+	}
+	return initialRating
+}
+
+// updateRatings rates the registered bots of a finished game against each other, a winner beats the losers,
+// players with the same status draw, anonymous players are not rated
+func updateRatings(g *game, gameResults []games.PlayerResult) {
+	var rated []games.PlayerResult
+	for _, result := range gameResults {
+		if p, ok := g.players[result.ID]; ok && p.BotID != uuid.Nil {
+			rated = append(rated, result)
+		}
+	}
+	if len(rated) < 2 {
+		return
+	}
+	ratingsLock.Lock()
+	defer ratingsLock.Unlock()
+	now := time.Now().UTC()
+	records := make([]*ratingRecord, len(rated))
+	for i, result := range rated {
+		key := ratingKey{gameName: g.name, botID: g.players[result.ID].BotID}
+		record, ok := ratings[key]
+		if !ok {
+			record = &ratingRecord{BotID: key.botID, GameName: key.gameName, Rating: initialRating}
+			ratings[key] = record
+		}
+		records[i] = record
+	}
+	// every bot plays a match against every other one, the changes are computed from the ratings before the game
+	changes := make([]float64, len(rated))
+	for i := range rated {
+		for j := range rated {
+			if i == j {
+				continue
+			}
+			expected := 1 / (1 + math.Pow(10, (records[j].Rating-records[i].Rating)/400))
+			changes[i] += ratingK * (outcome(rated[i].Status, rated[j].Status) - expected) / float64(len(rated)-1)
+		}
+	}
+	for i, record := range records {
+		record.Rating += changes[i]
+		record.Games++
+		switch rated[i].Status {
+		case games.WIN:
+			record.Wins++
+		case games.LOSE:
+			record.Losses++
+		default:
+			record.Draws++
+		}
+		record.UpdatedAt = now
+	}
+	if err := saveRatings(); err != nil {
+		logger.Error(errors.Wrapf(err, "could not save ratings of game %s", g.id))
+	}
+}
+
+// outcome is the score of a player against another one in the Elo formula
+func outcome(status, otherStatus games.Status) float64 {
+	switch {
+	case status == otherStatus:
+		return 0.5
+	case status == games.WIN || otherStatus == games.LOSE:
+		return 1
+	case status == games.LOSE || otherStatus == games.WIN:
+		return 0
+	default:
+		return 0.5
+	}
+}
+
+func (r *ratingRecord) rating() Rating {
+	return Rating{
+		BotID:     r.BotID,
+		GameName:  r.GameName,
+		Rating:    r.Rating,
+		Games:     r.Games,
+		Wins:      r.Wins,
+		Losses:    r.Losses,
+		Draws:     r.Draws,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+// saveRatings writes every rating to the ratings file, it has to be called holding ratingsLock
+func saveRatings() error {
+	if ratingsFile == "" {
+		return nil
+	}
+	records := make([]*ratingRecord, 0, len(ratings))
+	for _, record := range ratings {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].GameName != records[j].GameName {
+			return records[i].GameName < records[j].GameName
+		}
+		return records[i].BotID.String() < records[j].BotID.String()
+	})
+	body, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "could not encode ratings")
+	}
+	tmp := ratingsFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, body, 0644); err != nil {
+		return errors.Wrap(err, "could not write ratings")
+	}
+	return errors.Wrap(os.Rename(tmp, ratingsFile), "could not write ratings")
+}
@@ -0,0 +1,358 @@
+package core
+
+import (
+	"botServer/core/events"
+	"botServer/core/games"
+	"encoding/json"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	replayFileExtension = ".jsonl"
+	// replayRetention is how long the replay of a finished game can still be read when replays are not saved
+	replayRetention = 10 * time.Minute
+)
+
+// The types of the replay records
+const (
+	replayGameCreated   = "gameCreated"
+	replayPlayerJoined  = "playerJoined"
+	replayPlayerLeft    = "playerLeft"
+	replayGameStarted   = "gameStarted"
+	replayMove          = "move"
+	replayRoundTimeout  = "roundTimeout"
+	replayRoundFinished = "roundFinished"
+	replayGameFinished  = "gameFinished"
+	replayGameAborted   = "gameAborted"
+)
+
+// replayRecord is a line of the replay of a game, the records of a game are in the order they happened
+type replayRecord struct {
+	// gameCreated, playerJoined, playerLeft, gameStarted, move, roundTimeout, roundFinished, gameFinished or gameAborted
+	Type string `json:"type"`
+	// When it happened, in RFC 3339 with fractional seconds
+	Time string      `json:"time"`
+	Body interface{} `json:"body"`
+}
+
+// replayGame holds the settings of a recorded game
+type replayGame struct {
+	GameID          string `json:"gameId"`
+	Game            string `json:"game"`
+	NumberOfPlayers int    `json:"numberOfPlayers"`
+	TotalRounds     int    `json:"totalRounds"`
+	// Seconds a player has to make a move in a round, missing if there is no limit
+	MoveTimeout   int                    `json:"moveTimeout,omitempty"`
+	TimeoutPolicy string                 `json:"timeoutPolicy"`
+	Options       map[string]interface{} `json:"options,omitempty"`
+}
+
+// replayPlayer is a player of a recorded game
+type replayPlayer struct {
+	PlayerID   string `json:"playerId"`
+	PlayerName string `json:"playerName"`
+	// Id of the registered bot, missing for anonymous players
+	BotID string `json:"botId,omitempty"`
+}
+
+// replayStart lists the players of a recorded game in the order they joined it
+type replayStart struct {
+	Players []replayPlayer `json:"players"`
+}
+
+// replayPlayerMove is a move made in a recorded game
+type replayPlayerMove struct {
+	Round      int         `json:"round"`
+	PlayerID   string      `json:"playerId"`
+	PlayerName string      `json:"playerName"`
+	Move       interface{} `json:"move"`
+	// Random is set when the server played the move because the player did not move in time
+	Random bool `json:"random,omitempty"`
+}
+
+// replayTimeout tells which players did not move in time in a round of a recorded game
+type replayTimeout struct {
+	Round   int      `json:"round"`
+	Policy  string   `json:"policy"`
+	Players []string `json:"players"`
+}
+
+// replayRound is the result of a round of a recorded game
+type replayRound struct {
+	Round     int `json:"round"`
+	NextRound int `json:"nextRound"`
+	// Replayed is set when the round is played again, like after a draw in rps
+	Replayed bool                   `json:"replayed,omitempty"`
+	Status   string                 `json:"status"`
+	Winner   string                 `json:"winner,omitempty"`
+	Moves    map[string]interface{} `json:"moves"`
+	Points   map[string]int         `json:"points"`
+	Scores   []replayScore          `json:"scores"`
+	// Game specific state after the round, like the board
+	GameState interface{} `json:"gameState,omitempty"`
+}
+
+// replayResult is the result of a recorded game
+type replayResult struct {
+	Status  string               `json:"status"`
+	Winner  string               `json:"winner,omitempty"`
+	Players []replayPlayerResult `json:"players"`
+	Scores  []replayScore        `json:"scores"`
+	// Game specific state at the end of the game, like the board
+	GameState interface{} `json:"gameState,omitempty"`
+}
+
+// replayPlayerResult is the result of a player in a recorded game
+type replayPlayerResult struct {
+	PlayerID   string `json:"playerId"`
+	PlayerName string `json:"playerName"`
+	Status     string `json:"status"`
+}
+
+// replayScore is the score of a player in a recorded game, the scores are from the first place to the last
+type replayScore struct {
+	PlayerName string `json:"playerName"`
+	PlayerID   string `json:"playerId"`
+	// Id of the registered bot, missing for anonymous players
+	BotID string `json:"botId,omitempty"`
+	Score int    `json:"score"`
+	Rank  int    `json:"rank"`
+}
+
+// replayAbort tells why a recorded game ended without a result
+type replayAbort struct {
+	Reason string `json:"reason"`
+}
+
+// replay is the record of everything that happened in a game, records are only ever appended to it
+type replay struct {
+	lock sync.Mutex
+	// saved replays are written to a file per game, the others are kept in lines
+	saved bool
+	file  *os.File
+	lines []byte
+}
+
+var (
+	replaysLock sync.Mutex
+	// replays are the replays being recorded, and the unsaved replays of the recently finished games
+	replays = make(map[uuid.UUID]*replay)
+	// replayDir is where the replays are saved, they are only kept in memory when it is empty
+	replayDir string
+)
+
+// SetReplayDir saves the replay of every game in the given directory, as a JSON lines file per game
+func SetReplayDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "could not create replay directory")
+	}
+	replaysLock.Lock()
+	defer replaysLock.Unlock()
+	replayDir = dir
+	return nil
+}
+
+// GameReplay returns the records of the game as JSON lines, the replay of a running game cannot be read,
+// as its moves would tell the opponents what to play in the current round
+func GameReplay(gameID uuid.UUID) ([]byte, error) {
+	if _, ok := store.Get(gameID); ok {
+		return nil, errors.New("could not get replay: game is not over yet")
+	}
+	replaysLock.Lock()
+	r, ok := replays[gameID]
+	dir := replayDir
+	replaysLock.Unlock()
+	if ok {
+		// holding the lock of the replay makes sure no record is read half written
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		if !r.saved {
+			return append([]byte(nil), r.lines...), nil
+		}
+	}
+	if dir == "" {
+		return nil, errors.New("could not get replay: game id is not correct")
+	}
+	body, err := ioutil.ReadFile(replayPath(dir, gameID))
+	if os.IsNotExist(err) {
+		return nil, errors.New("could not get replay: game id is not correct")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get replay")
+	}
+	return body, nil
+}
+
+// recordReplay appends a record to the replay of the game, the game goes on even if it cannot be written
+func recordReplay(g *game, recordType string, body interface{}) {
+	line, err := json.Marshal(replayRecord{
+		Type: recordType,
+		Time: time.Now().UTC().Format(time.RFC3339Nano),
+		Body: body,
+	})
+	if err != nil {
+		logger.Error(errors.Wrapf(err, "could not encode %s record of game %s", recordType, g.id))
+		return
+	}
+	line = append(line, '\n')
+	r := openReplay(g.id)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.saved {
+		r.lines = append(r.lines, line...)
+		return
+	}
+	if r.file == nil {
+		return
+	}
+	if _, err := r.file.Write(line); err != nil {
+		logger.Error(errors.Wrapf(err, "could not write %s record of game %s", recordType, g.id))
+	}
+}
+
+// openReplay returns the replay of the game, a saved replay is appended to after a restart
+func openReplay(gameID uuid.UUID) *replay {
+	replaysLock.Lock()
+	defer replaysLock.Unlock()
+	if r, ok := replays[gameID]; ok {
+		return r
+	}
+	r := &replay{saved: replayDir != ""}
+	if r.saved {
+		file, err := os.OpenFile(replayPath(replayDir, gameID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "could not open replay of game %s", gameID))
+		}
+		r.file = file
+	}
+	replays[gameID] = r
+	return r
+}
+
+// closeReplay stops recording the game, an unsaved replay is dropped after replayRetention
+func closeReplay(gameID uuid.UUID) {
+	replaysLock.Lock()
+	r, ok := replays[gameID]
+	if ok && r.saved {
+		delete(replays, gameID)
+	}
+	replaysLock.Unlock()
+	if !ok {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if !r.saved {
+		time.AfterFunc(replayRetention, func() {
+			replaysLock.Lock()
+			defer replaysLock.Unlock()
+			delete(replays, gameID)
+		})
+		return
+	}
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			logger.Error(errors.Wrapf(err, "could not close replay of game %s", gameID))
+		}
+		r.file = nil
+	}
+}
+
+func replayPath(dir string, gameID uuid.UUID) string {
+	return filepath.Join(dir, gameID.String()+replayFileExtension)
+}
+
+func recordGameCreated(g *game) {
+	recordReplay(g, replayGameCreated, replayGame{
+		GameID:          g.id.String(),
+		Game:            g.name,
+		NumberOfPlayers: g.numberOfPlayers,
+		TotalRounds:     g.totalRounds,
+		MoveTimeout:     int(g.moveTimeout / time.Second),
+		TimeoutPolicy:   string(g.timeoutPolicy),
+		Options:         g.options,
+	})
+}
+
+func recordGameStarted(g *game) {
+	players := make([]replayPlayer, 0, len(g.playerOrder))
+	for _, id := range g.playerOrder {
+		players = append(players, newReplayPlayer(g.players[id]))
+	}
+	recordReplay(g, replayGameStarted, replayStart{Players: players})
+}
+
+func recordMove(g *game, p *Player, random bool) {
+	recordReplay(g, replayMove, replayPlayerMove{
+		Round:      g.currentRound,
+		PlayerID:   p.ID.String(),
+		PlayerName: p.Name,
+		Move:       p.currentMove,
+		Random:     random,
+	})
+}
+
+func recordRoundTimeout(g *game, timedOutPlayers []string) {
+	recordReplay(g, replayRoundTimeout, replayTimeout{
+		Round:   g.currentRound,
+		Policy:  string(g.timeoutPolicy),
+		Players: timedOutPlayers,
+	})
+}
+
+func recordRoundFinished(g *game, oldRound int, result games.RoundResult, moves []games.PlayerMove) {
+	roundFinished := roundFinishedEvent(g, oldRound, result, moves)
+	recordReplay(g, replayRoundFinished, replayRound{
+		Round:     oldRound,
+		NextRound: g.currentRound,
+		Replayed:  result.Replay,
+		Status:    roundFinished.Status,
+		Winner:    roundFinished.Winner,
+		Moves:     roundFinished.Moves,
+		Points:    roundFinished.Points,
+		Scores:    newReplayScores(roundFinished.Scores),
+		GameState: roundFinished.GameState,
+	})
+}
+
+func recordGameFinished(g *game, gameResults []games.PlayerResult, status games.Status, winner string, gameState interface{}) {
+	players := make([]replayPlayerResult, 0, len(gameResults))
+	for _, result := range gameResults {
+		p := g.players[result.ID]
+		players = append(players, replayPlayerResult{PlayerID: p.ID.String(), PlayerName: p.Name, Status: string(result.Status)})
+	}
+	recordReplay(g, replayGameFinished, replayResult{
+		Status:    string(status),
+		Winner:    winner,
+		Players:   players,
+		Scores:    newReplayScores(computeScores(g)),
+		GameState: gameState,
+	})
+}
+
+func newReplayPlayer(p *Player) replayPlayer {
+	player := replayPlayer{PlayerID: p.ID.String(), PlayerName: p.Name}
+	if p.BotID != uuid.Nil {
+		player.BotID = p.BotID.String()
+	}
+	return player
+}
+
+func newReplayScores(scores []events.Score) []replayScore {
+	result := make([]replayScore, 0, len(scores))
+	for _, score := range scores {
+		replayScore := replayScore{PlayerName: score.PlayerName, PlayerID: score.PlayerID.String(), Score: score.Score, Rank: score.Rank}
+		if score.BotID != uuid.Nil {
+			replayScore.BotID = score.BotID.String()
+		}
+		result = append(result, replayScore)
+	}
+	return result
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/google/uuid"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestGameReplayIsServedOnceTheGameIsOver checks that the replay of a game cannot be read while the game can still be played,
+// neither while it waits for its players nor while it is played, and that it has the whole game once it is over
+func TestGameReplayIsServedOnceTheGameIsOver(t *testing.T) {
+	callbacks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer callbacks.Close()
+	callback, err := url.Parse(callbacks.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := "replay-" + uuid.New().String()
+	var players []ConnectResponse
+	for _, name := range []string{"first", "second"} {
+		response, err := Connect(ConnectRequest{
+			GameName:      "rps",
+			Token:         token,
+			PlayerName:    name,
+			EventCallback: callback,
+			TotalRounds:   1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		players = append(players, response)
+		if _, err := GameReplay(response.GameID); err == nil {
+			t.Fatalf("the replay of the game was served after %s joined", name)
+		}
+	}
+	gameID := players[0].GameID
+	g, ok := store.Get(gameID)
+	if !ok {
+		t.Fatal("the game is not in the store")
+	}
+	waitForStart(t, g)
+
+	for i, move := range []string{"rock", "scissors"} {
+		if _, err := GameReplay(gameID); err == nil {
+			t.Fatalf("the replay of the game was served before move %d", i+1)
+		}
+		_, err := Play(PlayRequest{
+			GameID:   gameID,
+			PlayerID: players[i].Player.ID,
+			Token:    players[i].Player.Token,
+			Round:    1,
+			Move:     move,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var recordTypes []string
+	for _, record := range readReplay(t, gameID) {
+		recordTypes = append(recordTypes, record.Type)
+	}
+	want := []string{
+		replayGameCreated, replayPlayerJoined, replayPlayerJoined, replayGameStarted,
+		replayMove, replayMove, replayRoundFinished, replayGameFinished,
+	}
+	if len(recordTypes) != len(want) {
+		t.Fatalf("replay has records %v, want %v", recordTypes, want)
+	}
+	for i := range want {
+		if recordTypes[i] != want[i] {
+			t.Fatalf("replay has records %v, want %v", recordTypes, want)
+		}
+	}
+}
+
+// encodedRecord is a replay record with its body left encoded
+type encodedRecord struct {
+	Type string          `json:"type"`
+	Body json.RawMessage `json:"body"`
+}
+
+// readReplay waits until the replay of the game can be read, and returns its records
+func readReplay(t *testing.T, gameID uuid.UUID) []encodedRecord {
+	t.Helper()
+	deadline := time.Now().Add(startWait)
+	body, err := GameReplay(gameID)
+	for err != nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		body, err = GameReplay(gameID)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []encodedRecord
+	for _, line := range bytes.Split(bytes.TrimSpace(body), []byte("\n")) {
+		var record encodedRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"sync"
+)
+
+// GameStore keeps the games that are waiting for players or are in progress
+type GameStore interface {
+	// Add stores a new game, unless a game with the same token already exists,
+	// in which case the existing game is returned instead
+	Add(g *game) (*game, error)
+	// Get returns the game with the given id
+	Get(id uuid.UUID) (*game, bool)
+	// GetByToken returns the game that players connect to using the given token
+	GetByToken(token string) (*game, bool)
+	// Update saves the changes made to a game
+	Update(g *game) error
+	// Remove deletes a game from the store
+	Remove(id uuid.UUID) error
+	// List returns all the games in the store
+	List() []*game
+}
+
+type memoryStore struct {
+	lock          sync.RWMutex
+	tokenToGameID map[string]uuid.UUID
+	gameIDToGame  map[uuid.UUID]*game
+}
+
+// NewMemoryStore creates a game store that keeps everything in memory
+func NewMemoryStore() GameStore {
+	return newMemoryStore()
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		tokenToGameID: make(map[string]uuid.UUID),
+		gameIDToGame:  make(map[uuid.UUID]*game),
+	}
+}
+
+func (s *memoryStore) Add(g *game) (*game, error) {
+	if g.token == "" {
+		return nil, errors.New("token is empty")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if gameID, ok := s.tokenToGameID[g.token]; ok {
+		return s.gameIDToGame[gameID], nil
+	}
+	s.tokenToGameID[g.token] = g.id
+	s.gameIDToGame[g.id] = g
+	return g, nil
+}
+
+func (s *memoryStore) Get(id uuid.UUID) (*game, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	g, ok := s.gameIDToGame[id]
+	return g, ok
+}
+
+func (s *memoryStore) GetByToken(token string) (*game, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	gameID, ok := s.tokenToGameID[token]
+	if !ok {
+		return nil, false
+	}
+	g, ok := s.gameIDToGame[gameID]
+	return g, ok
+}
+
+func (s *memoryStore) Update(g *game) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if _, ok := s.gameIDToGame[g.id]; !ok {
+		return errors.Errorf("game %s does not exist", g.id)
+	}
+	return nil
+}
+
+func (s *memoryStore) Remove(id uuid.UUID) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	g, ok := s.gameIDToGame[id]
+	if !ok {
+		return nil
+	}
+	delete(s.tokenToGameID, g.token)
+	delete(s.gameIDToGame, id)
+	return nil
+}
+
+func (s *memoryStore) List() []*game {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	games := make([]*game, 0, len(s.gameIDToGame))
+	for _, g := range s.gameIDToGame {
+		games = append(games, g)
+	}
+	return games
+}
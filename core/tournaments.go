@@ -0,0 +1,773 @@
+package core
+
+import (
+	"botServer/core/events"
+	"botServer/core/games"
+	"context"
+	"crypto/subtle"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The formats of a tournament
+const (
+	FormatRoundRobin         = "round-robin"
+	FormatSwiss              = "swiss"
+	FormatSingleElimination  = "single-elimination"
+	FormatDoubleElimination  = "double-elimination"
+	tournamentPlayersPerGame = 2
+)
+
+// The statuses of a tournament
+const (
+	TournamentRegistering = "registering"
+	TournamentRunning     = "running"
+	TournamentFinished    = "finished"
+)
+
+// The statuses of a game of a tournament
+const (
+	TournamentGamePlaying = "playing"
+	TournamentGameDone    = "finished"
+	// TournamentGameForfeited is a game that ended without a result, like when a bot did not connect to it
+	TournamentGameForfeited = "forfeited"
+)
+
+// TournamentRequest is the input for the CreateTournament operation in the core
+type TournamentRequest struct {
+	GameName      string
+	TotalRounds   int
+	MoveTimeout   time.Duration
+	TimeoutPolicy string
+	GameOptions   map[string]interface{}
+	Format        string
+	// BotIDs are the registered bots playing, in the order of their seeds
+	BotIDs []uuid.UUID
+	// Rounds is the number of rounds of a Swiss tournament, enough to find a winner when 0
+	Rounds int
+}
+
+// EntryRequest is the input for the EnterTournament operation in the core
+type EntryRequest struct {
+	TournamentID  uuid.UUID
+	APIKey        string
+	EventCallback *url.URL
+	LegacyScore   bool
+	Polling       bool
+}
+
+// Entry is a bot that entered a tournament, it plays every game of the tournament with its token and signing secret
+type Entry struct {
+	ID            uuid.UUID
+	BotID         uuid.UUID
+	BotName       string
+	Token         string
+	SigningSecret string
+}
+
+// Tournament is a competition of registered bots, who play games of two players against each other
+type Tournament struct {
+	ID       uuid.UUID
+	GameName string
+	Format   string
+	Status   string
+	// Round is the round being played, 0 while bots are entering
+	Round int
+	// Rounds is the number of rounds, 0 for elimination tournaments, which end when one bot is left
+	Rounds    int
+	Standings []events.Standing
+	Games     []events.TournamentGame
+	CreatedAt time.Time
+}
+
+type tournament struct {
+	lock sync.Mutex
+	Tournament
+	request  TournamentRequest
+	entrants []*entrant
+	games    []*tournamentGame
+	// log numbers the tournamentUpdate events and keeps the ones sent to every entrant
+	log *events.GameLog
+	// scheduling is set while the bots are connected to the games of a new round
+	scheduling bool
+}
+
+type entrant struct {
+	Entry
+	seed     int
+	entered  bool
+	request  ConnectRequest
+	callback *events.HTTPTransport
+	points   float64
+	wins     int
+	losses   int
+	draws    int
+	byes     int
+	// eliminatedIn is the round the bot was knocked out of an elimination tournament in, 0 while it is still in
+	eliminatedIn int
+	opponents    map[uuid.UUID]bool
+}
+
+type tournamentGame struct {
+	round int
+	// token is the connection token the bots join the game with
+	token     string
+	gameID    uuid.UUID
+	players   []*entrant
+	playerIDs []uuid.UUID
+	status    string
+	winner    *entrant
+}
+
+// gameOutcome is the result of a finished game of a tournament
+type gameOutcome struct {
+	// token is the connection token of the game
+	token string
+	// winnerToken is the player token of the bot who won, empty for a draw
+	winnerToken string
+	// finished is false when the game ended without a result
+	finished bool
+}
+
+var (
+	tournamentsLock sync.Mutex
+	tournaments     = make(map[uuid.UUID]*tournament)
+	// tournamentGames has the tournament of every game played in one, by the connection token of the game,
+	// so that a game is known before its bots joined it
+	tournamentGames = make(map[string]*tournament)
+)
+
+// CreateTournament creates a tournament, it starts once every bot entered it
+func CreateTournament(req TournamentRequest) (Tournament, error) {
+	gameType, err := games.NewGame(req.GameName)
+	if err != nil {
+		return Tournament{}, errors.Wrap(err, "could not create tournament")
+	}
+	if !gameType.Validate(tournamentPlayersPerGame) {
+		err := errors.Errorf("%s cannot be played by two players", req.GameName)
+		return Tournament{}, errors.Wrap(err, "could not create tournament")
+	}
+	if _, err := getTimeoutPolicy(req.TimeoutPolicy); err != nil {
+		return Tournament{}, errors.Wrap(err, "could not create tournament")
+	}
+	if req.MoveTimeout < 0 {
+		return Tournament{}, errors.New("could not create tournament: move timeout cannot be negative")
+	}
+	if err := gameType.ValidateOptions(games.Options(req.GameOptions)); err != nil {
+		return Tournament{}, errors.Wrap(err, "could not create tournament")
+	}
+	if len(req.BotIDs) < 2 {
+		return Tournament{}, errors.New("could not create tournament: at least two bots have to play")
+	}
+	names := botNames()
+	t := &tournament{
+		Tournament: Tournament{
+			ID:        uuid.New(),
+			GameName:  req.GameName,
+			Format:    req.Format,
+			Status:    TournamentRegistering,
+			CreatedAt: time.Now().UTC(),
+		},
+		request: req,
+	}
+	for i, botID := range req.BotIDs {
+		name, ok := names[botID]
+		if !ok {
+			return Tournament{}, errors.Errorf("could not create tournament: bot %s is not registered", botID)
+		}
+		for _, e := range t.entrants {
+			if e.BotID == botID {
+				return Tournament{}, errors.Errorf("could not create tournament: bot %s is listed twice", name)
+			}
+		}
+		t.entrants = append(t.entrants, &entrant{
+			Entry:     Entry{BotID: botID, BotName: name},
+			seed:      i + 1,
+			opponents: make(map[uuid.UUID]bool),
+		})
+	}
+	switch req.Format {
+	case FormatRoundRobin:
+		t.Rounds = len(t.entrants) - 1 + len(t.entrants)%2
+	case FormatSwiss:
+		t.Rounds = req.Rounds
+		if t.Rounds == 0 {
+			t.Rounds = int(math.Ceil(math.Log2(float64(len(t.entrants)))))
+		}
+		if t.Rounds < 1 || t.Rounds >= len(t.entrants)+len(t.entrants)%2 {
+			return Tournament{}, errors.New("could not create tournament: a swiss tournament needs fewer rounds than bots")
+		}
+	case FormatSingleElimination, FormatDoubleElimination:
+	default:
+		err := errors.Errorf("format should be %s, %s, %s or %s", FormatRoundRobin, FormatSwiss,
+			FormatSingleElimination, FormatDoubleElimination)
+		return Tournament{}, errors.Wrap(err, "could not create tournament")
+	}
+	// the log is only found through the tournament, so the game endpoints do not serve it
+	t.log = events.NewLog(0)
+
+	tournamentsLock.Lock()
+	tournaments[t.ID] = t
+	tournamentsLock.Unlock()
+	logger.Infof("Tournament %s of %s was created for %d bots", t.ID, t.GameName, len(t.entrants))
+	return t.snapshot(), nil
+}
+
+// GetTournament returns the tournament with its standings
+func GetTournament(tournamentID uuid.UUID) (Tournament, error) {
+	t, ok := getTournament(tournamentID)
+	if !ok {
+		return Tournament{}, errors.New("could not get tournament: tournament id is not correct")
+	}
+	return t.snapshot(), nil
+}
+
+// EnterTournament signs up one of the bots of the tournament, the tournament starts when the last one enters
+func EnterTournament(req EntryRequest) (Entry, error) {
+	t, ok := getTournament(req.TournamentID)
+	if !ok {
+		return Entry{}, errors.New("could not enter tournament: tournament id is not correct")
+	}
+	bot, err := authenticateBot(req.APIKey)
+	if err != nil {
+		return Entry{}, errors.Wrap(err, "could not enter tournament")
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var e *entrant
+	for _, candidate := range t.entrants {
+		if candidate.BotID == bot.ID {
+			e = candidate
+		}
+	}
+	if e == nil {
+		return Entry{}, errors.Errorf("could not enter tournament: bot %s does not play in it", bot.Name)
+	}
+	if e.entered {
+		return Entry{}, errors.Errorf("could not enter tournament: bot %s already entered", bot.Name)
+	}
+	e.ID = uuid.New()
+	e.Token = newSecret()
+	e.SigningSecret = newSecret()
+	e.entered = true
+	e.callback = newCallbackTransport(req.EventCallback, e.SigningSecret)
+	e.request = ConnectRequest{
+		GameName:      t.GameName,
+		NoOfPlayers:   tournamentPlayersPerGame,
+		EventCallback: req.EventCallback,
+		TotalRounds:   t.request.TotalRounds,
+		MoveTimeout:   t.request.MoveTimeout,
+		TimeoutPolicy: t.request.TimeoutPolicy,
+		GameOptions:   t.request.GameOptions,
+		LegacyScore:   req.LegacyScore,
+		Polling:       req.Polling,
+		APIKey:        req.APIKey,
+		PlayerToken:   e.Token,
+		SigningSecret: e.SigningSecret,
+	}
+	t.log.AddPlayer(e.ID)
+	logger.Infof("Bot %s entered tournament %s", bot.Name, t.ID)
+	for _, other := range t.entrants {
+		if !other.entered {
+			return e.Entry, nil
+		}
+	}
+	t.Status = TournamentRunning
+	go t.nextRound()
+	return e.Entry, nil
+}
+
+// TournamentEvents returns the tournamentUpdate events sent to the entrant after the given sequence number,
+// waiting at most wait for one to be published
+func TournamentEvents(ctx context.Context, tournamentID, entryID uuid.UUID, token string, since int, wait time.Duration) ([]events.Event, error) {
+	t, ok := getTournament(tournamentID)
+	if !ok {
+		return nil, errors.New("could not get tournament events: tournament id is not correct")
+	}
+	t.lock.Lock()
+	var e *entrant
+	for _, candidate := range t.entrants {
+		if candidate.entered && candidate.ID == entryID {
+			e = candidate
+		}
+	}
+	t.lock.Unlock()
+	if e == nil || subtle.ConstantTimeCompare([]byte(e.Token), []byte(token)) != 1 {
+		return nil, errors.Wrap(ErrUnauthorized, "could not get tournament events")
+	}
+	return t.log.Wait(ctx, entryID, since, wait), nil
+}
+
+// reportTournamentGame tells the tournament of the game, if it belongs to one, that the game ended,
+// the results are nil when it ended without a result
+func reportTournamentGame(g *game, gameResults []games.PlayerResult) {
+	tournamentsLock.Lock()
+	t, ok := tournamentGames[g.token]
+	tournamentsLock.Unlock()
+	if !ok {
+		return
+	}
+	outcome := gameOutcome{token: g.token, finished: gameResults != nil}
+	var winners []string
+	for _, result := range gameResults {
+		if p, ok := g.players[result.ID]; ok && result.Status == games.WIN {
+			winners = append(winners, p.Token)
+		}
+	}
+	if len(winners) == 1 {
+		outcome.winnerToken = winners[0]
+	}
+	// the game goroutine does not wait for the next round to be scheduled
+	go t.finishGame(outcome)
+}
+
+// nextRound schedules the games of the next round, or finishes the tournament,
+// the bots join their games without the lock of the tournament, which the games take when they end
+func (t *tournament) nextRound() {
+	t.lock.Lock()
+	var pairings [][]*entrant
+	if t.Rounds == 0 || t.Round < t.Rounds {
+		pairings = t.pairings()
+	}
+	if len(pairings) == 0 {
+		t.Status = TournamentFinished
+		t.publishUpdate()
+		t.log.Close(t.ID)
+		logger.Infof("Tournament %s finished, %s won", t.ID, t.standings()[0].BotName)
+		t.lock.Unlock()
+		return
+	}
+	t.Round++
+	t.scheduling = true
+	var scheduled []*tournamentGame
+	for i, players := range pairings {
+		if len(players) == 1 {
+			t.giveBye(players[0])
+			continue
+		}
+		scheduled = append(scheduled, t.schedule(players, "tournament-"+t.ID.String()+"-"+strconv.Itoa(t.Round)+"-"+strconv.Itoa(i)))
+	}
+	t.lock.Unlock()
+
+	for _, tg := range scheduled {
+		t.connect(tg)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.scheduling = false
+	t.publishUpdate()
+	if t.roundFinished() {
+		go t.nextRound()
+	}
+}
+
+// schedule adds a game of the round, registered under its connection token before any bot joins it,
+// it has to be called holding the lock of the tournament
+func (t *tournament) schedule(players []*entrant, token string) *tournamentGame {
+	tg := &tournamentGame{round: t.Round, token: token, players: players, status: TournamentGamePlaying}
+	t.games = append(t.games, tg)
+	for _, e := range players {
+		for _, opponent := range players {
+			if opponent != e {
+				e.opponents[opponent.BotID] = true
+			}
+		}
+	}
+	tournamentsLock.Lock()
+	tournamentGames[token] = t
+	tournamentsLock.Unlock()
+	return tg
+}
+
+// connect joins the bots to their game, the game is forfeited when one of them cannot join,
+// it has to be called without holding the lock of the tournament
+func (t *tournament) connect(tg *tournamentGame) {
+	var responses []ConnectResponse
+	for _, e := range tg.players {
+		req := e.request
+		req.Token = tg.token
+		response, err := Connect(req)
+		if err != nil {
+			logger.Error(errors.Wrapf(err, "bot %s could not join its game in tournament %s", e.BotName, t.ID))
+			for i, joined := range responses {
+				if err := Leave(joined.GameID, joined.Player.ID, tg.players[i].Token); err != nil {
+					logger.Error(err)
+				}
+			}
+			t.finishGame(gameOutcome{token: tg.token})
+			return
+		}
+		responses = append(responses, response)
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	tg.gameID = responses[0].GameID
+	for _, response := range responses {
+		tg.playerIDs = append(tg.playerIDs, response.Player.ID)
+	}
+}
+
+func (t *tournament) finishGame(outcome gameOutcome) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, tg := range t.games {
+		if tg.token != outcome.token || tg.status != TournamentGamePlaying {
+			continue
+		}
+		t.applyOutcome(tg, outcome)
+		tournamentsLock.Lock()
+		delete(tournamentGames, tg.token)
+		tournamentsLock.Unlock()
+		// the update with the games of a round being scheduled is published once every bot joined its game
+		if t.scheduling {
+			return
+		}
+		t.publishUpdate()
+		if t.roundFinished() {
+			go t.nextRound()
+		}
+		return
+	}
+}
+
+// applyOutcome records the result of the game, in an elimination tournament a game without a winner
+// goes to the better seed, it has to be called holding the lock of the tournament
+func (t *tournament) applyOutcome(tg *tournamentGame, outcome gameOutcome) {
+	tg.status = TournamentGameDone
+	if !outcome.finished {
+		tg.status = TournamentGameForfeited
+	}
+	for _, e := range tg.players {
+		if outcome.winnerToken != "" && e.Token == outcome.winnerToken {
+			tg.winner = e
+		}
+	}
+	if tg.winner == nil && t.isElimination() {
+		tg.winner = tg.players[0]
+		for _, e := range tg.players {
+			if e.seed < tg.winner.seed {
+				tg.winner = e
+			}
+		}
+	}
+	for _, e := range tg.players {
+		switch {
+		case tg.winner == e:
+			e.wins++
+			e.points++
+		case tg.winner != nil || !outcome.finished:
+			e.losses++
+			if t.isElimination() && e.losses >= t.maxLosses() {
+				e.eliminatedIn = t.Round
+			}
+		default:
+			e.draws++
+			e.points += 0.5
+		}
+	}
+}
+
+// giveBye lets the bot skip the round, it is worth a win in a Swiss tournament
+func (t *tournament) giveBye(e *entrant) {
+	e.byes++
+	if t.Format == FormatSwiss {
+		e.points++
+	}
+}
+
+func (t *tournament) roundFinished() bool {
+	for _, tg := range t.games {
+		if tg.status == TournamentGamePlaying {
+			return false
+		}
+	}
+	return true
+}
+
+// pairings returns the bots playing each other in the next round, a bot alone gets a bye,
+// none when the tournament is over
+func (t *tournament) pairings() [][]*entrant {
+	switch t.Format {
+	case FormatRoundRobin:
+		return roundRobinPairings(t.entrants, t.Round)
+	case FormatSwiss:
+		return swissPairings(t.standingOrder())
+	default:
+		return eliminationPairings(t.entrants, t.maxLosses())
+	}
+}
+
+// roundRobinPairings pairs the bots with the circle method, the first bot stays in place and the others rotate
+func roundRobinPairings(entrants []*entrant, round int) [][]*entrant {
+	circle := append([]*entrant(nil), entrants...)
+	if len(circle)%2 == 1 {
+		circle = append(circle, nil)
+	}
+	rest := circle[1:]
+	shift := round % len(rest)
+	rotated := append(append([]*entrant{circle[0]}, rest[len(rest)-shift:]...), rest[:len(rest)-shift]...)
+	var pairings [][]*entrant
+	for i := 0; i < len(rotated)/2; i++ {
+		a, b := rotated[i], rotated[len(rotated)-1-i]
+		switch {
+		case a == nil:
+			pairings = append(pairings, []*entrant{b})
+		case b == nil:
+			pairings = append(pairings, []*entrant{a})
+		case round%2 == 1:
+			pairings = append(pairings, []*entrant{b, a})
+		default:
+			pairings = append(pairings, []*entrant{a, b})
+		}
+	}
+	return pairings
+}
+
+// swissPairings pairs every bot with the next one in the standings it did not play yet,
+// the last bot without a bye gets one when their number is odd, unless the others could then only be paired
+// with rematches, rematches are only played when they cannot be avoided
+func swissPairings(standing []*entrant) [][]*entrant {
+	if len(standing)%2 == 0 {
+		if pairings, ok := pairWithoutRematches(standing); ok {
+			return pairings
+		}
+		return pairInOrder(standing)
+	}
+	// the bots without a bye from the last one up, or the last bot when every bot had one
+	var byes []int
+	for i := len(standing) - 1; i >= 0; i-- {
+		if standing[i].byes == 0 {
+			byes = append(byes, i)
+		}
+	}
+	if len(byes) == 0 {
+		byes = append(byes, len(standing)-1)
+	}
+	var fallback [][]*entrant
+	for _, bye := range byes {
+		others := append(append([]*entrant(nil), standing[:bye]...), standing[bye+1:]...)
+		if pairings, ok := pairWithoutRematches(others); ok {
+			return append([][]*entrant{{standing[bye]}}, pairings...)
+		}
+		if fallback == nil {
+			fallback = append([][]*entrant{{standing[bye]}}, pairInOrder(others)...)
+		}
+	}
+	return fallback
+}
+
+// pairWithoutRematches pairs the first bot with the next one it did not play yet,
+// trying the following ones when the rest cannot be paired without a rematch then
+func pairWithoutRematches(unpaired []*entrant) ([][]*entrant, bool) {
+	if len(unpaired) == 0 {
+		return nil, true
+	}
+	for i := 1; i < len(unpaired); i++ {
+		if unpaired[0].opponents[unpaired[i].BotID] {
+			continue
+		}
+		rest := append(append([]*entrant(nil), unpaired[1:i]...), unpaired[i+1:]...)
+		if pairings, ok := pairWithoutRematches(rest); ok {
+			return append([][]*entrant{{unpaired[0], unpaired[i]}}, pairings...), true
+		}
+	}
+	return nil, false
+}
+
+// pairInOrder pairs every bot with the next one in the standings it did not play yet, or with the next one
+func pairInOrder(standing []*entrant) [][]*entrant {
+	var pairings [][]*entrant
+	unpaired := append([]*entrant(nil), standing...)
+	for len(unpaired) > 0 {
+		opponent := 1
+		for i := 1; i < len(unpaired); i++ {
+			if !unpaired[0].opponents[unpaired[i].BotID] {
+				opponent = i
+				break
+			}
+		}
+		pairings = append(pairings, []*entrant{unpaired[0], unpaired[opponent]})
+		unpaired = append(unpaired[1:opponent:opponent], unpaired[opponent+1:]...)
+	}
+	return pairings
+}
+
+// eliminationPairings pairs the bots with the same number of losses, the best seed against the worst,
+// the best seed with the fewest byes gets a bye when their number is odd,
+// the last two bots play the final even when they have a different number of losses
+func eliminationPairings(entrants []*entrant, maxLosses int) [][]*entrant {
+	brackets := make([][]*entrant, maxLosses)
+	var active []*entrant
+	for _, e := range entrants {
+		if e.losses < maxLosses {
+			brackets[e.losses] = append(brackets[e.losses], e)
+			active = append(active, e)
+		}
+	}
+	if len(active) < 2 {
+		return nil
+	}
+	if len(active) == 2 {
+		return [][]*entrant{active}
+	}
+	var pairings [][]*entrant
+	for _, bracket := range brackets {
+		if len(bracket)%2 == 1 {
+			bye := 0
+			for i, e := range bracket {
+				if e.byes < bracket[bye].byes {
+					bye = i
+				}
+			}
+			pairings = append(pairings, []*entrant{bracket[bye]})
+			bracket = append(bracket[:bye:bye], bracket[bye+1:]...)
+		}
+		for i := 0; i < len(bracket)/2; i++ {
+			pairings = append(pairings, []*entrant{bracket[i], bracket[len(bracket)-1-i]})
+		}
+	}
+	return pairings
+}
+
+func (t *tournament) isElimination() bool {
+	return t.Format == FormatSingleElimination || t.Format == FormatDoubleElimination
+}
+
+func (t *tournament) maxLosses() int {
+	if t.Format == FormatDoubleElimination {
+		return 2
+	}
+	return 1
+}
+
+// standingOrder returns the bots from the first place to the last, it has to be called holding the lock of the tournament
+func (t *tournament) standingOrder() []*entrant {
+	order := append([]*entrant(nil), t.entrants...)
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if t.isElimination() && a.eliminatedIn != b.eliminatedIn {
+			return a.eliminatedIn == 0 || (b.eliminatedIn != 0 && a.eliminatedIn > b.eliminatedIn)
+		}
+		if a.points != b.points {
+			return a.points > b.points
+		}
+		return a.seed < b.seed
+	})
+	return order
+}
+
+// standings ranks the bots, bots with the same points share the rank in the formats played for points,
+// it has to be called holding the lock of the tournament
+func (t *tournament) standings() []events.Standing {
+	order := t.standingOrder()
+	standings := make([]events.Standing, 0, len(order))
+	for i, e := range order {
+		standing := events.Standing{
+			Rank:       i + 1,
+			BotID:      e.BotID,
+			BotName:    e.BotName,
+			Points:     e.points,
+			Wins:       e.wins,
+			Losses:     e.losses,
+			Draws:      e.draws,
+			Byes:       e.byes,
+			Eliminated: e.eliminatedIn != 0,
+		}
+		if i > 0 && !t.isElimination() && e.points == order[i-1].points {
+			standing.Rank = standings[i-1].Rank
+		}
+		standings = append(standings, standing)
+	}
+	return standings
+}
+
+// roundGames returns the games of the current round, it has to be called holding the lock of the tournament
+func (t *tournament) roundGames() []events.TournamentGame {
+	var result []events.TournamentGame
+	for _, tg := range t.games {
+		if tg.round == t.Round {
+			result = append(result, tg.view())
+		}
+	}
+	return result
+}
+
+// publishUpdate sends the standings to every bot, with the game it has to play in the current round,
+// it has to be called holding the lock of the tournament
+func (t *tournament) publishUpdate() {
+	update := events.TournamentUpdate{
+		TournamentID: t.ID,
+		Status:       t.Status,
+		Round:        t.Round,
+		Standings:    t.standings(),
+		Games:        t.roundGames(),
+	}
+	for _, e := range t.entrants {
+		update.Subscribers = append(update.Subscribers, events.TournamentSubscriber{
+			Subscriber: e.subscriber(t.log),
+			Game:       t.currentGame(e),
+		})
+	}
+	events.PublishTournamentUpdate(update)
+}
+
+// currentGame returns the game the bot plays in the current round, nil when it has none
+func (t *tournament) currentGame(e *entrant) *events.PlayerGame {
+	for _, tg := range t.games {
+		// a game whose bots are still joining it has no player ids yet
+		if tg.round != t.Round || tg.status != TournamentGamePlaying || len(tg.playerIDs) < len(tg.players) {
+			continue
+		}
+		for i, player := range tg.players {
+			if player == e {
+				return &events.PlayerGame{GameID: tg.gameID, PlayerID: tg.playerIDs[i]}
+			}
+		}
+	}
+	return nil
+}
+
+func (t *tournament) snapshot() Tournament {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	result := t.Tournament
+	result.Standings = t.standings()
+	for _, tg := range t.games {
+		result.Games = append(result.Games, tg.view())
+	}
+	return result
+}
+
+func (tg *tournamentGame) view() events.TournamentGame {
+	view := events.TournamentGame{Round: tg.round, GameID: tg.gameID, Status: tg.status}
+	for _, e := range tg.players {
+		view.Players = append(view.Players, e.BotName)
+	}
+	if tg.winner != nil {
+		view.Winner = tg.winner.BotName
+	}
+	return view
+}
+
+func (e *entrant) subscriber(log *events.GameLog) events.Subscriber {
+	subscriber := events.Subscriber{Log: log, PlayerID: e.ID}
+	if e.callback != nil {
+		subscriber.Transport = e.callback
+	}
+	return subscriber
+}
+
+func getTournament(tournamentID uuid.UUID) (*tournament, bool) {
+	tournamentsLock.Lock()
+	defer tournamentsLock.Unlock()
+	t, ok := tournaments[tournamentID]
+	return t, ok
+}
@@ -0,0 +1,207 @@
+package core
+
+import (
+	"botServer/core/events"
+	"github.com/google/uuid"
+	"strconv"
+	"testing"
+)
+
+// newTestTournament creates a tournament of unregistered bots, the first bot is the best seed
+func newTestTournament(format string, bots, rounds int) *tournament {
+	t := &tournament{Tournament: Tournament{ID: uuid.New(), Format: format, Rounds: rounds}, log: events.NewLog(0)}
+	for i := 0; i < bots; i++ {
+		t.entrants = append(t.entrants, &entrant{
+			Entry:     Entry{ID: uuid.New(), BotID: uuid.New(), BotName: "bot" + strconv.Itoa(i+1), Token: newSecret()},
+			seed:      i + 1,
+			opponents: make(map[uuid.UUID]bool),
+		})
+	}
+	return t
+}
+
+// playTournament plays the rounds like nextRound does, without connecting the bots to games,
+// winner decides every game, a nil winner is a draw, it returns the pairings of every round
+func playTournament(t *tournament, winner func(a, b *entrant) *entrant) [][][]*entrant {
+	var rounds [][][]*entrant
+	for t.Rounds == 0 || t.Round < t.Rounds {
+		pairings := t.pairings()
+		if len(pairings) == 0 {
+			break
+		}
+		t.Round++
+		for i, players := range pairings {
+			if len(players) == 1 {
+				t.giveBye(players[0])
+				continue
+			}
+			tg := t.schedule(players, "test-"+t.ID.String()+"-"+strconv.Itoa(t.Round)+"-"+strconv.Itoa(i))
+			outcome := gameOutcome{token: tg.token, finished: true}
+			if w := winner(players[0], players[1]); w != nil {
+				outcome.winnerToken = w.Token
+			}
+			t.applyOutcome(tg, outcome)
+			tournamentsLock.Lock()
+			delete(tournamentGames, tg.token)
+			tournamentsLock.Unlock()
+		}
+		rounds = append(rounds, pairings)
+	}
+	return rounds
+}
+
+func betterSeed(a, b *entrant) *entrant {
+	if a.seed < b.seed {
+		return a
+	}
+	return b
+}
+
+// countGames counts the games of every pair of bots and the byes of every bot, by bot name
+func countGames(rounds [][][]*entrant) (map[string]int, map[string]int) {
+	games, byes := make(map[string]int), make(map[string]int)
+	for _, pairings := range rounds {
+		for _, players := range pairings {
+			if len(players) == 1 {
+				byes[players[0].BotName]++
+				continue
+			}
+			a, b := players[0].BotName, players[1].BotName
+			if b < a {
+				a, b = b, a
+			}
+			games[a+"-"+b]++
+		}
+	}
+	return games, byes
+}
+
+func TestRoundRobinPairsEveryBotOnce(t *testing.T) {
+	for _, bots := range []int{2, 4, 5, 6} {
+		t.Run(strconv.Itoa(bots)+" bots", func(t *testing.T) {
+			tour := newTestTournament(FormatRoundRobin, bots, bots-1+bots%2)
+			games, byes := countGames(playTournament(tour, betterSeed))
+			if len(games) != bots*(bots-1)/2 {
+				t.Errorf("%d pairs of bots played, want %d", len(games), bots*(bots-1)/2)
+			}
+			for pair, count := range games {
+				if count != 1 {
+					t.Errorf("%s played %d times", pair, count)
+				}
+			}
+			for _, e := range tour.entrants {
+				if wantByes := bots % 2; byes[e.BotName] != wantByes {
+					t.Errorf("%s got %d byes, want %d", e.BotName, byes[e.BotName], wantByes)
+				}
+			}
+		})
+	}
+}
+
+func TestSwissAvoidsRematchesAndRepeatedByes(t *testing.T) {
+	tests := []struct {
+		bots   int
+		rounds int
+	}{
+		{bots: 8, rounds: 3},
+		{bots: 7, rounds: 3},
+		{bots: 5, rounds: 4},
+	}
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.bots)+" bots", func(t *testing.T) {
+			tour := newTestTournament(FormatSwiss, test.bots, test.rounds)
+			rounds := playTournament(tour, betterSeed)
+			if len(rounds) != test.rounds {
+				t.Fatalf("%d rounds were played, want %d", len(rounds), test.rounds)
+			}
+			games, byes := countGames(rounds)
+			for pair, count := range games {
+				if count != 1 {
+					t.Errorf("%s played %d times", pair, count)
+				}
+			}
+			for name, count := range byes {
+				if count != 1 {
+					t.Errorf("%s got %d byes", name, count)
+				}
+			}
+			if wantByes := test.rounds * (test.bots % 2); len(byes) != wantByes {
+				t.Errorf("%d bots got a bye, want %d", len(byes), wantByes)
+			}
+		})
+	}
+}
+
+func TestEliminationPlaysUntilOneBotIsLeft(t *testing.T) {
+	tests := []struct {
+		format string
+		bots   int
+		// losses is what every bot but the winner has lost when the tournament is over
+		losses int
+	}{
+		{format: FormatSingleElimination, bots: 4, losses: 1},
+		{format: FormatSingleElimination, bots: 5, losses: 1},
+		{format: FormatDoubleElimination, bots: 4, losses: 2},
+		{format: FormatDoubleElimination, bots: 6, losses: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.format+" of "+strconv.Itoa(test.bots)+" bots", func(t *testing.T) {
+			tour := newTestTournament(test.format, test.bots, 0)
+			playTournament(tour, betterSeed)
+			standings := tour.standings()
+			if standings[0].BotName != "bot1" || standings[0].Eliminated || standings[0].Losses != 0 {
+				t.Errorf("%s is first with %d losses, eliminated %t, want bot1 with no loss", standings[0].BotName, standings[0].Losses, standings[0].Eliminated)
+			}
+			for i, standing := range standings {
+				if standing.Rank != i+1 {
+					t.Errorf("%s is ranked %d, want %d", standing.BotName, standing.Rank, i+1)
+				}
+				if i > 0 && (!standing.Eliminated || standing.Losses != test.losses) {
+					t.Errorf("%s has %d losses and eliminated %t, want %d losses and eliminated", standing.BotName, standing.Losses, standing.Eliminated, test.losses)
+				}
+			}
+		})
+	}
+}
+
+func TestEliminationDrawGoesToTheBetterSeed(t *testing.T) {
+	tour := newTestTournament(FormatSingleElimination, 2, 0)
+	playTournament(tour, func(a, b *entrant) *entrant { return nil })
+	if standings := tour.standings(); standings[0].BotName != "bot1" || !standings[1].Eliminated {
+		t.Errorf("standings are %+v, want bot1 first and bot2 eliminated", standings)
+	}
+}
+
+func TestStandingsShareTheRankOnEqualPoints(t *testing.T) {
+	tour := newTestTournament(FormatRoundRobin, 3, 3)
+	// every game is a draw, so every bot has a point from its two draws and its bye is worth nothing
+	playTournament(tour, func(a, b *entrant) *entrant { return nil })
+	for _, standing := range tour.standings() {
+		if standing.Rank != 1 || standing.Points != 1 || standing.Draws != 2 || standing.Byes != 1 {
+			t.Errorf("%s is ranked %d with %v points, %d draws and %d byes, want rank 1 with 1 point, 2 draws and 1 bye",
+				standing.BotName, standing.Rank, standing.Points, standing.Draws, standing.Byes)
+		}
+	}
+}
+
+// TestTournamentLogIsNotAGameLog checks that the game endpoints do not serve the events of a tournament
+func TestTournamentLogIsNotAGameLog(t *testing.T) {
+	var botIDs []uuid.UUID
+	for i := 0; i < 2; i++ {
+		bot, _, err := CreateBot("log-" + uuid.New().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		botIDs = append(botIDs, bot.ID)
+	}
+	tour, err := CreateTournament(TournamentRequest{GameName: "rps", Format: FormatRoundRobin, BotIDs: botIDs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Spectate(tour.ID, events.NewChannelTransport(1), 0); err == nil {
+		t.Error("the tournament could be spectated as a game")
+	}
+	if _, ok := events.GetGameLog(tour.ID); ok {
+		t.Error("the log of the tournament is a game log")
+	}
+}
@@ -16,28 +16,70 @@ import (
 	"botServer/web"
 	"github.com/google/logger"
 	"log"
+	"math/rand"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"time"
 )
 
 func main() {
 	logger.Init("Bot Server", false, false, os.Stdout)
 	logger.Infof("Server started")
+	rand.Seed(time.Now().UnixNano())
 	port, err := strconv.Atoi(os.Getenv("PORT"))
 	if err != nil {
 		logger.Infof("Could not get port from env variables, falling back to 8080")
 		port = 8080
 	}
 
-	ConnectAPIService := web.NewConnectAPIService()
-	ConnectAPIController := web.NewConnectAPIController(ConnectAPIService)
+	if storeDir := os.Getenv("STORE_DIR"); storeDir != "" {
+		// the restored games start as soon as the store is set, so everything they use is loaded first
+		if err := core.LoadBots(filepath.Join(storeDir, "bots", "bots.json")); err != nil {
+			logger.Fatal(err)
+		}
+		if err := core.LoadRatings(filepath.Join(storeDir, "bots", "ratings.json")); err != nil {
+			logger.Fatal(err)
+		}
+		if err := core.SetReplayDir(filepath.Join(storeDir, "replays")); err != nil {
+			logger.Fatal(err)
+		}
+		store, err := core.NewFileStore(storeDir)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		core.SetGameStore(store)
+		logger.Infof("Games are persisted in %s", storeDir)
+	}
 
 	PlayAPIService := web.NewPlayAPIService()
 	PlayAPIController := web.NewPlayAPIController(PlayAPIService)
 
-	router := web.NewRouter(ConnectAPIController, PlayAPIController)
+	ConnectAPIService := web.NewConnectAPIService()
+	ConnectAPIController := web.NewConnectAPIController(ConnectAPIService, PlayAPIService)
+
+	EventsAPIService := web.NewEventsAPIService()
+	EventsAPIController := web.NewEventsAPIController(EventsAPIService)
+
+	GamesAPIService := web.NewGamesAPIService()
+	GamesAPIController := web.NewGamesAPIController(GamesAPIService)
+
+	MatchmakingAPIService := web.NewMatchmakingAPIService()
+	MatchmakingAPIController := web.NewMatchmakingAPIController(MatchmakingAPIService)
+
+	RatingsAPIService := web.NewRatingsAPIService()
+	RatingsAPIController := web.NewRatingsAPIController(RatingsAPIService)
+
+	AdminAPIService := web.NewAdminAPIService(os.Getenv("ADMIN_TOKEN"))
+	AdminAPIController := web.NewAdminAPIController(AdminAPIService)
+
+	TournamentsAPIService := web.NewTournamentsAPIService()
+	TournamentsAPIController := web.NewTournamentsAPIController(TournamentsAPIService, AdminAPIService)
+
+	router := web.NewRouter(ConnectAPIController, PlayAPIController, EventsAPIController, GamesAPIController, MatchmakingAPIController, RatingsAPIController, TournamentsAPIController, AdminAPIController)
 
 	core.StartCleaner()
+	core.StartMatchmaker()
 	log.Fatal(http.ListenAndServe(":"+strconv.Itoa(port), router))
 }
@@ -11,8 +11,9 @@
 package web
 
 import (
+	"botServer/core/events"
 	"botServer/web/model"
-	"github.com/gorilla/websocket"
+	"context"
 	"net/http"
 )
 
@@ -27,13 +28,100 @@ type PlayAPIRouter interface {
 	PlayPost(http.ResponseWriter, *http.Request)
 }
 
+// EventsAPIRouter is the router for the events API
+type EventsAPIRouter interface {
+	EventsGet(http.ResponseWriter, *http.Request)
+	EventsStreamGet(http.ResponseWriter, *http.Request)
+}
+
+// MatchmakingAPIRouter is the router for the matchmaking API
+type MatchmakingAPIRouter interface {
+	QueuePost(http.ResponseWriter, *http.Request)
+	QueueTicketGet(http.ResponseWriter, *http.Request)
+	QueueTicketDelete(http.ResponseWriter, *http.Request)
+}
+
+// RatingsAPIRouter is the router for the ratings API
+type RatingsAPIRouter interface {
+	RatingsGet(http.ResponseWriter, *http.Request)
+}
+
+// TournamentsAPIRouter is the router for the tournaments API
+type TournamentsAPIRouter interface {
+	TournamentsPost(http.ResponseWriter, *http.Request)
+	TournamentGet(http.ResponseWriter, *http.Request)
+	EntriesPost(http.ResponseWriter, *http.Request)
+	TournamentEventsGet(http.ResponseWriter, *http.Request)
+}
+
+// GamesAPIRouter is the router for the games API
+type GamesAPIRouter interface {
+	SpectateGet(http.ResponseWriter, *http.Request)
+	ReplayGet(http.ResponseWriter, *http.Request)
+}
+
+// AdminAPIRouter is the router for the admin API
+type AdminAPIRouter interface {
+	DeadLettersGet(http.ResponseWriter, *http.Request)
+	BotsGet(http.ResponseWriter, *http.Request)
+	BotsPost(http.ResponseWriter, *http.Request)
+	BotKeyPost(http.ResponseWriter, *http.Request)
+}
+
 // ConnectAPIServicer resolves the requests to the connect API
 type ConnectAPIServicer interface {
 	HelloPost(model.HelloRequest) (model.HelloResponse, error)
-	SwitchToWs(model.SwitchToWsRequest, *websocket.Conn) error
+	Authorize(model.SwitchToWsRequest) error
+	SwitchToWs(model.SwitchToWsRequest, *WebsocketConn) error
+	HelloWs(model.HelloRequest, *WebsocketConn) (model.HelloResponse, error)
+	DisconnectWs(model.SwitchToWsRequest, *WebsocketConn) error
+	Leave(model.LeaveRequest) error
 }
 
 // PlayAPIServicer resolves the requests to the play API
 type PlayAPIServicer interface {
 	PlayPost(model.PlayRequest) (model.PlayResponse, error)
 }
+
+// EventsAPIServicer resolves the requests to the events API
+type EventsAPIServicer interface {
+	EventsGet(context.Context, model.EventsRequest) ([]model.Event, error)
+	EventsStreamGet(model.EventsStreamRequest) (*events.ChannelTransport, error)
+	CloseEventsStream(model.EventsStreamRequest, *events.ChannelTransport)
+}
+
+// MatchmakingAPIServicer resolves the requests to the matchmaking API
+type MatchmakingAPIServicer interface {
+	QueuePost(model.QueueRequest) (model.Ticket, error)
+	QueueTicketGet(context.Context, model.TicketRequest) (model.Ticket, error)
+	QueueTicketDelete(model.TicketRequest) error
+}
+
+// RatingsAPIServicer resolves the requests to the ratings API
+type RatingsAPIServicer interface {
+	RatingsGet(model.RatingsRequest) ([]model.Rating, error)
+}
+
+// TournamentsAPIServicer resolves the requests to the tournaments API
+type TournamentsAPIServicer interface {
+	TournamentsPost(model.CreateTournamentRequest) (model.Tournament, error)
+	TournamentGet(tournamentID string) (model.Tournament, error)
+	EntriesPost(model.EntryRequest) (model.Entry, error)
+	TournamentEventsGet(context.Context, model.TournamentEventsRequest) ([]model.Event, error)
+}
+
+// GamesAPIServicer resolves the requests to the games API
+type GamesAPIServicer interface {
+	SpectateGet(model.SpectateRequest) (*events.ChannelTransport, error)
+	StopSpectating(model.SpectateRequest, *events.ChannelTransport)
+	ReplayGet(gameID string) ([]byte, error)
+}
+
+// AdminAPIServicer resolves the requests to the admin API
+type AdminAPIServicer interface {
+	Authorize(token string) error
+	DeadLettersGet() ([]model.DeadLetter, error)
+	BotsGet() ([]model.Bot, error)
+	BotsPost(model.CreateBotRequest) (model.BotAPIKey, error)
+	BotKeyPost(botID string) (model.BotAPIKey, error)
+}
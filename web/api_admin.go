@@ -0,0 +1,158 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/web/model"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+)
+
+// An AdminAPIController binds http requests to an api service and writes the service results to the http response,
+// every request needs the admin token as a bearer token
+type AdminAPIController struct {
+	service AdminAPIServicer
+}
+
+// NewAdminAPIController creates a default api controller
+func NewAdminAPIController(s AdminAPIServicer) Router {
+	return &AdminAPIController{service: s}
+}
+
+// Routes returns all of the api route for the AdminAPIController
+func (c *AdminAPIController) Routes() Routes {
+	return Routes{
+		{
+			"DeadLettersGet",
+			strings.ToUpper("Get"),
+			"/admin/dead-letters",
+			c.DeadLettersGet,
+		},
+		{
+			"BotsGet",
+			strings.ToUpper("Get"),
+			"/admin/bots",
+			c.BotsGet,
+		},
+		{
+			"BotsPost",
+			strings.ToUpper("Post"),
+			"/admin/bots",
+			c.BotsPost,
+		},
+		{
+			"BotKeyPost",
+			strings.ToUpper("Post"),
+			"/admin/bots/{botId}/key",
+			c.BotKeyPost,
+		},
+	}
+}
+
+// DeadLettersGet -
+func (c *AdminAPIController) DeadLettersGet(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r) {
+		return
+	}
+	result, err := c.service.DeadLettersGet()
+	if err != nil {
+		handleServerError(w, err)
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusOK, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
+
+// BotsGet -
+func (c *AdminAPIController) BotsGet(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r) {
+		return
+	}
+	result, err := c.service.BotsGet()
+	if err != nil {
+		handleServerError(w, err)
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusOK, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
+
+// BotsPost registers a bot, the response has the API key of the bot
+func (c *AdminAPIController) BotsPost(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r) {
+		return
+	}
+	createBotRequest := &model.CreateBotRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&createBotRequest); err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	result, err := c.service.BotsPost(*createBotRequest)
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusCreated, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
+
+// BotKeyPost rotates the API key of a bot, the previous key stops working
+func (c *AdminAPIController) BotKeyPost(w http.ResponseWriter, r *http.Request) {
+	if !c.authorize(w, r) {
+		return
+	}
+	result, err := c.service.BotKeyPost(mux.Vars(r)["botId"])
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusOK, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
+
+func (c *AdminAPIController) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if err := c.service.Authorize(bearerToken(r)); err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusUnauthorized, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return false
+	}
+	return true
+}
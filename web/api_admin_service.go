@@ -0,0 +1,99 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/core"
+	"botServer/core/events"
+	"botServer/web/model"
+	"crypto/subtle"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// AdminAPIService is a service that implements the logic for the AdminAPIServicer
+type AdminAPIService struct {
+	adminToken string
+}
+
+// NewAdminAPIService creates an admin api service, the admin API is disabled when the token is empty
+func NewAdminAPIService(adminToken string) AdminAPIServicer {
+	return &AdminAPIService{adminToken: adminToken}
+}
+
+// Authorize checks the token given by the caller against the admin token
+func (s *AdminAPIService) Authorize(token string) error {
+	if s.adminToken == "" {
+		return errors.New("admin API is disabled, set ADMIN_TOKEN to enable it")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+		return errors.New("invalid admin token")
+	}
+	return nil
+}
+
+// DeadLettersGet -
+func (s *AdminAPIService) DeadLettersGet() ([]model.DeadLetter, error) {
+	deadLetters := events.DeadLetters()
+	result := make([]model.DeadLetter, 0, len(deadLetters))
+	for _, deadLetter := range deadLetters {
+		result = append(result, model.DeadLetter{
+			ID:       deadLetter.ID.String(),
+			Callback: deadLetter.Callback,
+			Event:    deadLetter.Event,
+			Attempts: deadLetter.Attempts,
+			Error:    deadLetter.Error,
+			FailedAt: deadLetter.FailedAt.Format(time.RFC3339),
+		})
+	}
+	return result, nil
+}
+
+// BotsGet -
+func (s *AdminAPIService) BotsGet() ([]model.Bot, error) {
+	bots := core.Bots()
+	result := make([]model.Bot, 0, len(bots))
+	for _, bot := range bots {
+		result = append(result, toModelBot(bot))
+	}
+	return result, nil
+}
+
+// BotsPost -
+func (s *AdminAPIService) BotsPost(request model.CreateBotRequest) (model.BotAPIKey, error) {
+	bot, apiKey, err := core.CreateBot(request.Name)
+	if err != nil {
+		return model.BotAPIKey{}, err
+	}
+	return model.BotAPIKey{Bot: toModelBot(bot), APIKey: apiKey}, nil
+}
+
+// BotKeyPost -
+func (s *AdminAPIService) BotKeyPost(botID string) (model.BotAPIKey, error) {
+	id, err := uuid.Parse(botID)
+	if err != nil {
+		return model.BotAPIKey{}, errors.Wrap(err, "could not rotate api key: invalid bot id")
+	}
+	bot, apiKey, err := core.RotateBotKey(id)
+	if err != nil {
+		return model.BotAPIKey{}, err
+	}
+	return model.BotAPIKey{Bot: toModelBot(bot), APIKey: apiKey}, nil
+}
+
+func toModelBot(bot core.Bot) model.Bot {
+	return model.Bot{
+		ID:        bot.ID.String(),
+		Name:      bot.Name,
+		CreatedAt: bot.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -23,12 +23,14 @@ var Upgrader = websocket.Upgrader{}
 
 // A ConnectAPIController binds http requests to an api service and writes the service results to the http response
 type ConnectAPIController struct {
-	service ConnectAPIServicer
+	service     ConnectAPIServicer
+	playService PlayAPIServicer
 }
 
-// NewConnectAPIController creates a default api controller
-func NewConnectAPIController(s ConnectAPIServicer) Router {
-	return &ConnectAPIController{service: s}
+// NewConnectAPIController creates a default api controller,
+// the play service handles the moves sent over websocket connections
+func NewConnectAPIController(s ConnectAPIServicer, playService PlayAPIServicer) Router {
+	return &ConnectAPIController{service: s, playService: playService}
 }
 
 // Routes returns all of the api route for the ConnectAPIController
@@ -64,7 +66,7 @@ func (c *ConnectAPIController) HelloPost(w http.ResponseWriter, r *http.Request)
 	result, err := c.service.HelloPost(*helloRequest)
 	if err != nil {
 		errorResponse := &model.Error{Message: err.Error()}
-		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
 		if err != nil {
 			handleServerError(w, err)
 		}
@@ -77,8 +79,19 @@ func (c *ConnectAPIController) HelloPost(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// SwitchToWs upgrades to a websocket connection, without gameId and playerId
+// the player has to join a game by sending a hello message over the connection
 func (c *ConnectAPIController) SwitchToWs(w http.ResponseWriter, r *http.Request) {
-	gameId, ok := r.URL.Query()["gameId"]
+	query := r.URL.Query()
+	if query.Get("gameId") == "" && query.Get("playerId") == "" {
+		conn, ok := upgradeToWebsocket(w, r)
+		if !ok {
+			return
+		}
+		c.newWebsocketSession(conn, model.SwitchToWsRequest{}).serve()
+		return
+	}
+	gameId, ok := query["gameId"]
 	if !ok || len(gameId) < 1 || len(gameId[0]) < 1 {
 		errorResponse := &model.Error{Message: "Missing gameId in query string"}
 		err := EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
@@ -87,7 +100,7 @@ func (c *ConnectAPIController) SwitchToWs(w http.ResponseWriter, r *http.Request
 		}
 		return
 	}
-	playerId, ok := r.URL.Query()["playerId"]
+	playerId, ok := query["playerId"]
 	if !ok || len(playerId) < 1 || len(playerId[0]) < 1 {
 		errorResponse := &model.Error{Message: "Missing playerId in query string"}
 		err := EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
@@ -99,8 +112,22 @@ func (c *ConnectAPIController) SwitchToWs(w http.ResponseWriter, r *http.Request
 	switchToWsRequest := model.SwitchToWsRequest{
 		GameID:   gameId[0],
 		PlayerID: playerId[0],
+		LastSeq:  query.Get("lastSeq"),
+		Token:    bearerToken(r),
 	}
-	websocketConn, err := Upgrader.Upgrade(w, r, nil)
+	if err := c.service.Authorize(switchToWsRequest); err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+	conn, ok := upgradeToWebsocket(w, r)
+	if !ok {
+		return
+	}
+	err := c.service.SwitchToWs(switchToWsRequest, conn)
 	if err != nil {
 		err = errors.Wrap(err, "could not upgrade to websocket")
 		errorResponse := &model.Error{Message: err.Error()}
@@ -110,7 +137,22 @@ func (c *ConnectAPIController) SwitchToWs(w http.ResponseWriter, r *http.Request
 		}
 		return
 	}
-	err = c.service.SwitchToWs(switchToWsRequest, websocketConn)
+	c.newWebsocketSession(conn, switchToWsRequest).serve()
+}
+
+func (c *ConnectAPIController) newWebsocketSession(conn *WebsocketConn, request model.SwitchToWsRequest) *websocketSession {
+	return &websocketSession{
+		conn:           conn,
+		gameID:         request.GameID,
+		playerID:       request.PlayerID,
+		token:          request.Token,
+		connectService: c.service,
+		playService:    c.playService,
+	}
+}
+
+func upgradeToWebsocket(w http.ResponseWriter, r *http.Request) (*WebsocketConn, bool) {
+	websocketConn, err := Upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		err = errors.Wrap(err, "could not upgrade to websocket")
 		errorResponse := &model.Error{Message: err.Error()}
@@ -118,6 +160,7 @@ func (c *ConnectAPIController) SwitchToWs(w http.ResponseWriter, r *http.Request
 		if err != nil {
 			handleServerError(w, err)
 		}
-		return
+		return nil, false
 	}
+	return newWebsocketConn(websocketConn), true
 }
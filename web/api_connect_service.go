@@ -12,12 +12,14 @@ package web
 
 import (
 	"botServer/core"
+	"botServer/core/events"
 	"botServer/web/model"
 	"github.com/google/logger"
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 	"net/url"
+	"strconv"
+	"time"
 )
 
 // ConnectAPIService is a service that implements the logic for the ConnectAPIServicer
@@ -33,6 +35,17 @@ func NewConnectAPIService() ConnectAPIServicer {
 
 // HelloPost -
 func (s *ConnectAPIService) HelloPost(helloRequest model.HelloRequest) (model.HelloResponse, error) {
+	return hello(helloRequest, nil)
+}
+
+// HelloWs connects the player who sent a hello message through the given websocket,
+// every event of the game is sent through it
+func (s *ConnectAPIService) HelloWs(helloRequest model.HelloRequest, conn *WebsocketConn) (model.HelloResponse, error) {
+	return hello(helloRequest, conn)
+}
+
+// hello connects the player, the events are sent through the transport when it is not nil
+func hello(helloRequest model.HelloRequest, transport events.Transport) (model.HelloResponse, error) {
 	callbackURL, err := url.Parse(helloRequest.EventCallback)
 	if err != nil {
 		return model.HelloResponse{}, err
@@ -43,22 +56,36 @@ func (s *ConnectAPIService) HelloPost(helloRequest model.HelloRequest) (model.He
 		NoOfPlayers:   helloRequest.Game.NumberOfTotalPlayers,
 		PlayerName:    helloRequest.PlayerName,
 		EventCallback: callbackURL,
+		Transport:     transport,
 		TotalRounds:   helloRequest.Game.TotalRounds,
+		MoveTimeout:   time.Duration(helloRequest.Game.MoveTimeout) * time.Second,
+		TimeoutPolicy: helloRequest.Game.TimeoutPolicy,
+		GameOptions:   helloRequest.Game.Options,
+		LegacyScore:   helloRequest.LegacyScore,
+		Polling:       helloRequest.Polling,
+		APIKey:        helloRequest.APIKey,
 	})
 	if err != nil {
 		return model.HelloResponse{}, err
 	}
+	player := model.HelloResponsePlayer{
+		ID:   connectResponse.Player.ID.String(),
+		Name: connectResponse.Player.Name,
+	}
+	if connectResponse.Player.BotID != uuid.Nil {
+		player.BotID = connectResponse.Player.BotID.String()
+	}
 	return model.HelloResponse{
-		GameID: connectResponse.GameID.String(),
-		Rounds: connectResponse.Rounds,
-		Player: model.HelloResponsePlayer{
-			ID:   connectResponse.Player.ID.String(),
-			Name: connectResponse.Player.Name,
-		},
+		GameID:        connectResponse.GameID.String(),
+		Rounds:        connectResponse.Rounds,
+		SigningSecret: connectResponse.Player.SigningSecret,
+		Token:         connectResponse.Player.Token,
+		Player:        player,
 	}, nil
 }
 
-func (s *ConnectAPIService) SwitchToWs(request model.SwitchToWsRequest, conn *websocket.Conn) error {
+// Authorize checks the token of the player who wants to switch to a websocket connection
+func (s *ConnectAPIService) Authorize(request model.SwitchToWsRequest) error {
 	gameID, err := uuid.Parse(request.GameID)
 	if err != nil {
 		return errors.Wrap(err, "could not switch to WS: invalid game id")
@@ -67,8 +94,27 @@ func (s *ConnectAPIService) SwitchToWs(request model.SwitchToWsRequest, conn *we
 	if err != nil {
 		return errors.Wrap(err, "could not switch to WS: invalid player id")
 	}
+	return errors.Wrap(core.Authenticate(gameID, playerID, request.Token), "could not switch to WS")
+}
+
+func (s *ConnectAPIService) SwitchToWs(request model.SwitchToWsRequest, conn *WebsocketConn) error {
+	gameID, err := uuid.Parse(request.GameID)
+	if err != nil {
+		return errors.Wrap(err, "could not switch to WS: invalid game id")
+	}
+	playerID, err := uuid.Parse(request.PlayerID)
+	if err != nil {
+		return errors.Wrap(err, "could not switch to WS: invalid player id")
+	}
+	lastSeq := -1
+	if request.LastSeq != "" {
+		lastSeq, err = strconv.Atoi(request.LastSeq)
+		if err != nil {
+			return errors.Wrap(err, "could not switch to WS: invalid last seq")
+		}
+	}
 
-	err = core.RegisterWS(gameID, playerID, conn)
+	err = core.RegisterTransport(gameID, playerID, request.Token, conn, lastSeq)
 	if err != nil {
 		closeWebsocket(conn)
 		return err
@@ -76,13 +122,34 @@ func (s *ConnectAPIService) SwitchToWs(request model.SwitchToWsRequest, conn *we
 	return nil
 }
 
-func closeWebsocket(conn *websocket.Conn) {
-	cm := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "game does not exist")
-	if err := conn.WriteMessage(websocket.CloseMessage, cm); err != nil {
+// DisconnectWs -
+func (s *ConnectAPIService) DisconnectWs(request model.SwitchToWsRequest, conn *WebsocketConn) error {
+	gameID, err := uuid.Parse(request.GameID)
+	if err != nil {
+		return errors.Wrap(err, "could not disconnect WS: invalid game id")
+	}
+	playerID, err := uuid.Parse(request.PlayerID)
+	if err != nil {
+		return errors.Wrap(err, "could not disconnect WS: invalid player id")
+	}
+	return core.DisconnectTransport(gameID, playerID, conn)
+}
+
+// Leave -
+func (s *ConnectAPIService) Leave(request model.LeaveRequest) error {
+	gameID, err := uuid.Parse(request.GameID)
+	if err != nil {
+		return errors.Wrap(err, "could not leave game: invalid game id")
+	}
+	playerID, err := uuid.Parse(request.PlayerID)
+	if err != nil {
+		return errors.Wrap(err, "could not leave game: invalid player id")
+	}
+	return core.Leave(gameID, playerID, request.Token)
+}
+
+func closeWebsocket(conn *WebsocketConn) {
+	if err := conn.Close("game does not exist"); err != nil {
 		logger.Error(err)
-		err = conn.Close()
-		if err != nil {
-			logger.Error(err)
-		}
 	}
 }
@@ -0,0 +1,167 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/core/events"
+	"botServer/web/model"
+	"encoding/json"
+	"fmt"
+	"github.com/google/logger"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// streamKeepAlive is how often a comment is written to an idle event stream, so that proxies keep it open
+const streamKeepAlive = 15 * time.Second
+
+// An EventsAPIController binds http requests to an api service and writes the service results to the http response
+type EventsAPIController struct {
+	service EventsAPIServicer
+}
+
+// NewEventsAPIController creates a default api controller
+func NewEventsAPIController(s EventsAPIServicer) Router {
+	return &EventsAPIController{service: s}
+}
+
+// Routes returns all of the api route for the EventsAPIController
+func (c *EventsAPIController) Routes() Routes {
+	return Routes{
+		{
+			"EventsGet",
+			strings.ToUpper("Get"),
+			"/events",
+			c.EventsGet,
+		},
+		{
+			"EventsStreamGet",
+			strings.ToUpper("Get"),
+			"/events/stream",
+			c.EventsStreamGet,
+		},
+	}
+}
+
+// EventsGet waits for the events of a player, for bots that cannot receive them through a callback or websocket
+func (c *EventsAPIController) EventsGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	eventsRequest := model.EventsRequest{
+		GameID:   query.Get("gameId"),
+		PlayerID: query.Get("playerId"),
+		Since:    query.Get("since"),
+		Token:    bearerToken(r),
+	}
+	result, err := c.service.EventsGet(r.Context(), eventsRequest)
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusOK, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
+
+// EventsStreamGet serves the events of a player as server-sent events until the game is finished,
+// a reconnecting client gets the events after its Last-Event-ID again
+func (c *EventsAPIController) EventsStreamGet(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		handleServerError(w, errors.New("streaming is not supported"))
+		return
+	}
+	query := r.URL.Query()
+	streamRequest := model.EventsStreamRequest{
+		GameID:      query.Get("gameId"),
+		PlayerID:    query.Get("playerId"),
+		LastEventID: r.Header.Get("Last-Event-ID"),
+		Token:       bearerToken(r),
+	}
+	// EventSource cannot set headers on its first request
+	if streamRequest.LastEventID == "" {
+		streamRequest.LastEventID = query.Get("lastEventId")
+	}
+	stream, err := c.service.EventsStreamGet(streamRequest)
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+	defer c.service.CloseEventsStream(streamRequest, stream)
+	serveEventStream(w, r, flusher, stream)
+}
+
+// serveEventStream writes the events of the stream as server-sent events, until the game is finished,
+// the stream is closed or the client goes away, the reason a stream was closed is sent as a comment
+func serveEventStream(w http.ResponseWriter, r *http.Request, flusher http.Flusher, stream *events.ChannelTransport) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event := <-stream.Events():
+			if err := writeServerSentEvent(w, event); err != nil {
+				logger.Error(errors.Wrap(err, "could not write to event stream"))
+				return
+			}
+			flusher.Flush()
+			if event.Type == "gameFinished" {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-stream.Done():
+			// the events sent before the stream was closed, like the last ones of a game, are still written
+			for {
+				select {
+				case event := <-stream.Events():
+					if err := writeServerSentEvent(w, event); err != nil {
+						return
+					}
+				default:
+					_, _ = fmt.Fprintf(w, ": %s\n\n", stream.Reason())
+					flusher.Flush()
+					return
+				}
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeServerSentEvent(w http.ResponseWriter, event model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrapf(err, "could not encode %s event", event.Type)
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+	return err
+}
@@ -0,0 +1,99 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/core"
+	"botServer/core/events"
+	"botServer/web/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"strconv"
+	"time"
+)
+
+// streamBuffer is how many events can wait for a stream to write them
+const streamBuffer = 32
+
+// pollTimeout is how long a poll waits for a new event before answering with none
+const pollTimeout = 30 * time.Second
+
+// EventsAPIService is a service that implements the logic for the EventsAPIServicer
+type EventsAPIService struct {
+}
+
+// NewEventsAPIService creates a default api service
+func NewEventsAPIService() EventsAPIServicer {
+	return &EventsAPIService{}
+}
+
+// EventsGet returns the events of the player after the since sequence number, waiting for one if there are none yet,
+// the result is empty when none was published in time or when the game is over
+func (s *EventsAPIService) EventsGet(ctx context.Context, request model.EventsRequest) ([]model.Event, error) {
+	gameID, err := uuid.Parse(request.GameID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get events: invalid game id")
+	}
+	playerID, err := uuid.Parse(request.PlayerID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get events: invalid player id")
+	}
+	since := 0
+	if request.Since != "" {
+		since, err = strconv.Atoi(request.Since)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get events: invalid since")
+		}
+	}
+	result, err := core.Events(ctx, gameID, playerID, request.Token, since, pollTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		result = []model.Event{}
+	}
+	return result, nil
+}
+
+// EventsStreamGet opens a stream for the events of the player, the events after the last event id are sent first
+func (s *EventsAPIService) EventsStreamGet(request model.EventsStreamRequest) (*events.ChannelTransport, error) {
+	gameID, err := uuid.Parse(request.GameID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not stream events: invalid game id")
+	}
+	playerID, err := uuid.Parse(request.PlayerID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not stream events: invalid player id")
+	}
+	lastSeq := -1
+	if request.LastEventID != "" {
+		lastSeq, err = strconv.Atoi(request.LastEventID)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not stream events: invalid last event id")
+		}
+	}
+	stream := events.NewChannelTransport(streamBuffer)
+	if err := core.RegisterTransport(gameID, playerID, request.Token, stream, lastSeq); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// CloseEventsStream closes the stream, and tells the game the player no longer gets events through it
+func (s *EventsAPIService) CloseEventsStream(request model.EventsStreamRequest, stream *events.ChannelTransport) {
+	_ = stream.Close("stream closed")
+	gameID, gameErr := uuid.Parse(request.GameID)
+	playerID, playerErr := uuid.Parse(request.PlayerID)
+	if gameErr == nil && playerErr == nil {
+		_ = core.DisconnectTransport(gameID, playerID, stream)
+	}
+}
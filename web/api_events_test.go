@@ -0,0 +1,31 @@
+package web
+
+import (
+	"botServer/web/model"
+	"github.com/google/uuid"
+	"net/http"
+	"testing"
+)
+
+// TestEventsStreamNeedsTheTokenInTheHeader checks that the player token is not accepted as a query parameter
+func TestEventsStreamNeedsTheTokenInTheHeader(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	var player model.HelloResponse
+	status := postJSON(t, server.URL+"/hello", "", model.HelloRequest{
+		Game:    model.HelloRequestGame{Name: "rps", ConnectionToken: "stream-" + uuid.New().String()},
+		Polling: true,
+	}, &player)
+	if status != http.StatusOK {
+		t.Fatalf("could not join the game: %d", status)
+	}
+
+	resp, err := http.Get(server.URL + "/events/stream?gameId=" + player.GameID + "&playerId=" + player.Player.ID + "&token=" + player.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("the stream answered %d with the token in the query, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
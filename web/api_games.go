@@ -0,0 +1,97 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/web/model"
+	"github.com/google/logger"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+)
+
+// A GamesAPIController binds http requests to an api service and writes the service results to the http response
+type GamesAPIController struct {
+	service GamesAPIServicer
+}
+
+// NewGamesAPIController creates a default api controller
+func NewGamesAPIController(s GamesAPIServicer) Router {
+	return &GamesAPIController{service: s}
+}
+
+// Routes returns all of the api route for the GamesAPIController
+func (c *GamesAPIController) Routes() Routes {
+	return Routes{
+		{
+			"SpectateGet",
+			strings.ToUpper("Get"),
+			"/games/{gameId}/spectate",
+			c.SpectateGet,
+		},
+		{
+			"ReplayGet",
+			strings.ToUpper("Get"),
+			"/games/{gameId}/replay",
+			c.ReplayGet,
+		},
+	}
+}
+
+// SpectateGet serves every event of a game as server-sent events until the game is finished, without joining it,
+// a reconnecting client gets the events after its Last-Event-ID again
+func (c *GamesAPIController) SpectateGet(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		handleServerError(w, errors.New("streaming is not supported"))
+		return
+	}
+	spectateRequest := model.SpectateRequest{
+		GameID:      mux.Vars(r)["gameId"],
+		LastEventID: r.Header.Get("Last-Event-ID"),
+	}
+	// EventSource cannot set headers on its first request
+	if spectateRequest.LastEventID == "" {
+		spectateRequest.LastEventID = r.URL.Query().Get("lastEventId")
+	}
+	stream, err := c.service.SpectateGet(spectateRequest)
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+	defer c.service.StopSpectating(spectateRequest, stream)
+	serveEventStream(w, r, flusher, stream)
+}
+
+// ReplayGet serves the replay of a game as JSON lines, a record of everything that happened in it,
+// from its settings to its result
+func (c *GamesAPIController) ReplayGet(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.ReplayGet(mux.Vars(r)["gameId"])
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(result); err != nil {
+		logger.Error(errors.Wrap(err, "could not write replay"))
+	}
+}
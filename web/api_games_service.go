@@ -0,0 +1,66 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/core"
+	"botServer/core/events"
+	"botServer/web/model"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"strconv"
+)
+
+// GamesAPIService is a service that implements the logic for the GamesAPIServicer
+type GamesAPIService struct {
+}
+
+// NewGamesAPIService creates a default api service
+func NewGamesAPIService() GamesAPIServicer {
+	return &GamesAPIService{}
+}
+
+// SpectateGet opens a stream for the events of the game, the events after the last event id are sent first
+func (s *GamesAPIService) SpectateGet(request model.SpectateRequest) (*events.ChannelTransport, error) {
+	gameID, err := uuid.Parse(request.GameID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not spectate game: invalid game id")
+	}
+	lastSeq := 0
+	if request.LastEventID != "" {
+		lastSeq, err = strconv.Atoi(request.LastEventID)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not spectate game: invalid last event id")
+		}
+	}
+	stream := events.NewChannelTransport(streamBuffer)
+	if err := core.Spectate(gameID, stream, lastSeq); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// ReplayGet returns the replay of the game, as a JSON lines document
+func (s *GamesAPIService) ReplayGet(gameID string) ([]byte, error) {
+	id, err := uuid.Parse(gameID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get replay: invalid game id")
+	}
+	return core.GameReplay(id)
+}
+
+// StopSpectating closes the stream, and tells the game the spectator no longer gets events through it
+func (s *GamesAPIService) StopSpectating(request model.SpectateRequest, stream *events.ChannelTransport) {
+	_ = stream.Close("stream closed")
+	if gameID, err := uuid.Parse(request.GameID); err == nil {
+		core.StopSpectating(gameID, stream)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/web/model"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+)
+
+// A MatchmakingAPIController binds http requests to an api service and writes the service results to the http response
+type MatchmakingAPIController struct {
+	service MatchmakingAPIServicer
+}
+
+// NewMatchmakingAPIController creates a default api controller
+func NewMatchmakingAPIController(s MatchmakingAPIServicer) Router {
+	return &MatchmakingAPIController{service: s}
+}
+
+// Routes returns all of the api route for the MatchmakingAPIController
+func (c *MatchmakingAPIController) Routes() Routes {
+	return Routes{
+		{
+			"QueuePost",
+			strings.ToUpper("Post"),
+			"/matchmaking/queue",
+			c.QueuePost,
+		},
+		{
+			"QueueTicketGet",
+			strings.ToUpper("Get"),
+			"/matchmaking/queue/{ticketId}",
+			c.QueueTicketGet,
+		},
+		{
+			"QueueTicketDelete",
+			strings.ToUpper("Delete"),
+			"/matchmaking/queue/{ticketId}",
+			c.QueueTicketDelete,
+		},
+	}
+}
+
+// QueuePost puts a bot in the matchmaking queue
+func (c *MatchmakingAPIController) QueuePost(w http.ResponseWriter, r *http.Request) {
+	queueRequest := &model.QueueRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&queueRequest); err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	result, err := c.service.QueuePost(*queueRequest)
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusCreated, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
+
+// QueueTicketGet waits for a ticket to be matched, for bots that cannot receive the matchFound event through a callback
+func (c *MatchmakingAPIController) QueueTicketGet(w http.ResponseWriter, r *http.Request) {
+	ticketRequest := model.TicketRequest{
+		TicketID: mux.Vars(r)["ticketId"],
+		Token:    bearerToken(r),
+	}
+	result, err := c.service.QueueTicketGet(r.Context(), ticketRequest)
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusOK, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
+
+// QueueTicketDelete takes a waiting ticket out of the queue
+func (c *MatchmakingAPIController) QueueTicketDelete(w http.ResponseWriter, r *http.Request) {
+	ticketRequest := model.TicketRequest{
+		TicketID: mux.Vars(r)["ticketId"],
+		Token:    bearerToken(r),
+	}
+	if err := c.service.QueueTicketDelete(ticketRequest); err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,100 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/core"
+	"botServer/web/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"math"
+	"net/url"
+	"time"
+)
+
+// MatchmakingAPIService is a service that implements the logic for the MatchmakingAPIServicer
+type MatchmakingAPIService struct {
+}
+
+// NewMatchmakingAPIService creates a default api service
+func NewMatchmakingAPIService() MatchmakingAPIServicer {
+	return &MatchmakingAPIService{}
+}
+
+// QueuePost puts the bot in the matchmaking queue, the response has the token and signing secret of the ticket
+func (s *MatchmakingAPIService) QueuePost(request model.QueueRequest) (model.Ticket, error) {
+	callbackURL, err := url.Parse(request.EventCallback)
+	if err != nil {
+		return model.Ticket{}, err
+	}
+	ticket, err := core.JoinQueue(core.QueueRequest{
+		GameName:      request.Game.Name,
+		NoOfPlayers:   request.Game.NumberOfTotalPlayers,
+		PlayerName:    request.PlayerName,
+		EventCallback: callbackURL,
+		LegacyScore:   request.LegacyScore,
+		Polling:       request.Polling,
+		APIKey:        request.APIKey,
+	})
+	if err != nil {
+		return model.Ticket{}, err
+	}
+	result := toModelTicket(ticket)
+	result.Token = ticket.Token
+	result.SigningSecret = ticket.SigningSecret
+	return result, nil
+}
+
+// QueueTicketGet returns the ticket, waiting for it to be matched if it is still waiting
+func (s *MatchmakingAPIService) QueueTicketGet(ctx context.Context, request model.TicketRequest) (model.Ticket, error) {
+	ticketID, err := uuid.Parse(request.TicketID)
+	if err != nil {
+		return model.Ticket{}, errors.Wrap(err, "could not get ticket: invalid ticket id")
+	}
+	ticket, err := core.WaitForMatch(ctx, ticketID, request.Token, pollTimeout)
+	if err != nil {
+		return model.Ticket{}, err
+	}
+	return toModelTicket(ticket), nil
+}
+
+// QueueTicketDelete takes the ticket out of the queue
+func (s *MatchmakingAPIService) QueueTicketDelete(request model.TicketRequest) error {
+	ticketID, err := uuid.Parse(request.TicketID)
+	if err != nil {
+		return errors.Wrap(err, "could not leave queue: invalid ticket id")
+	}
+	return core.LeaveQueue(ticketID, request.Token)
+}
+
+func toModelTicket(ticket core.Ticket) model.Ticket {
+	result := model.Ticket{
+		TicketID:             ticket.ID.String(),
+		Game:                 ticket.GameName,
+		NumberOfTotalPlayers: ticket.NumberOfPlayers,
+		Status:               ticket.Status,
+		Rating:               int(math.Round(ticket.Rating)),
+		Error:                ticket.Error,
+		EnqueuedAt:           ticket.EnqueuedAt.Format(time.RFC3339),
+	}
+	if ticket.Match != nil {
+		result.Match = &model.MatchFound{
+			TicketID: ticket.ID.String(),
+			GameID:   ticket.Match.GameID.String(),
+			PlayerID: ticket.Match.PlayerID.String(),
+			Game:     ticket.GameName,
+			Players:  ticket.Match.Players,
+			Rounds:   ticket.Match.Rounds,
+		}
+	}
+	return result
+}
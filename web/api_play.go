@@ -53,12 +53,13 @@ func (c *PlayAPIController) PlayPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	playRequest.Token = bearerToken(r)
 	result, err := c.service.PlayPost(*playRequest)
 	if err != nil {
 		message := err.Error()
 		logger.Warning(message)
 		errorResponse := &model.Error{Message: message}
-		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
 		if err != nil {
 			handleServerError(w, err)
 		}
@@ -44,6 +44,7 @@ func (s *PlayAPIService) PlayPost(playRequest model.PlayRequest) (model.PlayResp
 	playResponse, err := core.Play(core.PlayRequest{
 		GameID:   gameID,
 		PlayerID: playerID,
+		Token:    playRequest.Token,
 		Round:    playRequest.Round,
 		Move:     playRequest.Move.Value,
 	})
@@ -0,0 +1,338 @@
+package web
+
+import (
+	"botServer/web/model"
+	"bytes"
+	"encoding/json"
+	"github.com/google/logger"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	// concurrentRequests is how many requests of every kind are sent at the same time in a round
+	concurrentRequests = 20
+	testRounds         = 9
+	// roundsToWin is how many rounds a player has to win to win the game
+	roundsToWin = testRounds/2 + 1
+	eventsWait  = 5 * time.Second
+	// timeoutRounds is the number of rounds in the game where every round times out
+	timeoutRounds = 3
+)
+
+// testEvent is an event received by a test player, with the fields the tests check
+type testEvent struct {
+	Type string `json:"type"`
+	Body struct {
+		Round      int          `json:"round"`
+		GameResult model.Result `json:"gameResult"`
+	} `json:"body"`
+}
+
+func TestMain(m *testing.M) {
+	logger.Init("Bot Server test", false, false, ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+// TestConcurrentRequestsToOneGame sends many moves of both players of a game at the same time,
+// together with players trying to join the full game and players switching to websockets,
+// every round has to be finished by exactly one of the moves
+func TestConcurrentRequestsToOneGame(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	received := make(chan testEvent, 100)
+	callbacks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event testEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error(err)
+		}
+		received <- event
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer callbacks.Close()
+
+	token := "concurrent-" + uuid.New().String()
+	var players []model.HelloResponse
+	for _, name := range []string{"first", "second"} {
+		var response model.HelloResponse
+		status := postJSON(t, server.URL+"/hello", "", model.HelloRequest{
+			Game: model.HelloRequestGame{
+				Name:            "rps",
+				ConnectionToken: token,
+				TotalRounds:     testRounds,
+			},
+			PlayerName:    name,
+			EventCallback: callbacks.URL,
+		}, &response)
+		if status != http.StatusOK {
+			t.Fatalf("%s could not join the game: %d", name, status)
+		}
+		players = append(players, response)
+	}
+	waitForEvents(t, received, map[string]int{"startGame": len(players)})
+
+	var (
+		websocketsLock sync.Mutex
+		websockets     []*websocket.Conn
+	)
+	defer func() {
+		for _, conn := range websockets {
+			_ = conn.Close()
+		}
+	}()
+	// the first player always wins, so the game is over once the first player won the majority of the rounds
+	moves := []string{"rock", "scissors"}
+	for round := 1; round <= roundsToWin; round++ {
+		var (
+			wg         sync.WaitGroup
+			lock       sync.Mutex
+			finishings int
+		)
+		for i, p := range players {
+			for j := 0; j < concurrentRequests; j++ {
+				wg.Add(1)
+				go func(p model.HelloResponse, round int, move string) {
+					defer wg.Done()
+					var response model.PlayResponse
+					status := postJSON(t, server.URL+"/play", p.Token, model.PlayRequest{
+						GameID:   p.GameID,
+						PlayerID: p.Player.ID,
+						Round:    round,
+						Move:     model.Move{Value: move},
+					}, &response)
+					// the move that finished the round is the only one answered with the next round
+					if status == http.StatusOK && response.Round == round+1 {
+						lock.Lock()
+						finishings++
+						lock.Unlock()
+					}
+				}(p, round, moves[i])
+			}
+		}
+		if round < roundsToWin {
+			for j := 0; j < concurrentRequests; j++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var response model.HelloResponse
+					status := postJSON(t, server.URL+"/hello", "", model.HelloRequest{
+						Game:          model.HelloRequestGame{Name: "rps", ConnectionToken: token},
+						EventCallback: callbacks.URL,
+					}, &response)
+					if status != http.StatusBadRequest {
+						t.Errorf("joining the full game answered %d, want %d", status, http.StatusBadRequest)
+					}
+				}()
+			}
+			for _, p := range players {
+				wg.Add(1)
+				go func(p model.HelloResponse) {
+					defer wg.Done()
+					conn := switchToWebsocket(t, server.URL, p, received)
+					if conn == nil {
+						return
+					}
+					websocketsLock.Lock()
+					websockets = append(websockets, conn)
+					websocketsLock.Unlock()
+				}(p)
+			}
+		}
+		wg.Wait()
+		if finishings != 1 {
+			t.Fatalf("round %d was finished %d times", round, finishings)
+		}
+	}
+
+	for _, event := range waitForEvents(t, received, map[string]int{"gameFinished": len(players)}) {
+		if event.Type == "gameFinished" && event.Body.GameResult.Winner != "first" {
+			t.Errorf("the winner of the game is %q, want %q", event.Body.GameResult.Winner, "first")
+		}
+	}
+}
+
+// TestTimeoutsRaceWithPlays lets every round time out while the only player who moves keeps sending moves,
+// every round has to time out exactly once and be won by that player
+func TestTimeoutsRaceWithPlays(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	received := make(chan testEvent, 100)
+	callbacks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event testEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error(err)
+		}
+		received <- event
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer callbacks.Close()
+
+	token := "timeouts-" + uuid.New().String()
+	var players []model.HelloResponse
+	for _, name := range []string{"first", "second"} {
+		var response model.HelloResponse
+		status := postJSON(t, server.URL+"/hello", "", model.HelloRequest{
+			Game: model.HelloRequestGame{
+				Name:            "rps",
+				ConnectionToken: token,
+				TotalRounds:     timeoutRounds,
+				MoveTimeout:     1,
+				TimeoutPolicy:   "forfeit",
+			},
+			PlayerName:    name,
+			EventCallback: callbacks.URL,
+		}, &response)
+		if status != http.StatusOK {
+			t.Fatalf("%s could not join the game: %d", name, status)
+		}
+		players = append(players, response)
+	}
+
+	// the first player sends moves for every round all the time, only the ones of the current round are accepted
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for j := 0; j < concurrentRequests; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				for round := 1; round <= timeoutRounds; round++ {
+					select {
+					case <-stop:
+						return
+					case <-time.After(10 * time.Millisecond):
+					}
+					var response model.PlayResponse
+					postJSON(t, server.URL+"/play", players[0].Token, model.PlayRequest{
+						GameID:   players[0].GameID,
+						PlayerID: players[0].Player.ID,
+						Round:    round,
+						Move:     model.Move{Value: "rock"},
+					}, &response)
+				}
+			}
+		}()
+	}
+	// the first player wins the game with the majority of the rounds
+	wonRounds := timeoutRounds/2 + 1
+	gameEvents := waitForEvents(t, received, map[string]int{
+		"roundTimeout": wonRounds * len(players),
+		"gameFinished": len(players),
+	})
+	close(stop)
+	wg.Wait()
+
+	timeouts := make(map[int]int)
+	for _, event := range gameEvents {
+		switch event.Type {
+		case "roundTimeout":
+			timeouts[event.Body.Round]++
+		case "gameFinished":
+			if event.Body.GameResult.Winner != "first" {
+				t.Errorf("the winner of the game is %q, want %q", event.Body.GameResult.Winner, "first")
+			}
+		}
+	}
+	for round := 1; round <= wonRounds; round++ {
+		if timeouts[round] != len(players) {
+			t.Errorf("round %d timed out %d times, want once for each of the %d players", round, timeouts[round]/len(players), len(players))
+		}
+	}
+}
+
+// newTestServer serves the API the way the bot server does
+func newTestServer() *httptest.Server {
+	playService := NewPlayAPIService()
+	return httptest.NewServer(NewRouter(
+		NewConnectAPIController(NewConnectAPIService(), playService),
+		NewPlayAPIController(playService),
+		NewEventsAPIController(NewEventsAPIService()),
+	))
+}
+
+// postJSON posts the request as JSON with the player token if given,
+// and decodes the response into the given value, it returns the status code
+func postJSON(t *testing.T, url, token string, request, response interface{}) int {
+	body, err := json.Marshal(request)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+			t.Error(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// switchToWebsocket connects the player through a websocket, and forwards the events it receives
+func switchToWebsocket(t *testing.T, serverURL string, p model.HelloResponse, received chan<- testEvent) *websocket.Conn {
+	wsURL := "ws" + strings.TrimPrefix(serverURL, "http") + "/ws?gameId=" + p.GameID + "&playerId=" + p.Player.ID
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Authorization": {"Bearer " + p.Token}})
+	if err != nil {
+		t.Error(err)
+		return nil
+	}
+	go func() {
+		for {
+			var event testEvent
+			if err := conn.ReadJSON(&event); err != nil {
+				return
+			}
+			received <- event
+		}
+	}()
+	return conn
+}
+
+// waitForEvents waits until the wanted number of events of every given type arrive,
+// and returns every event received until then
+func waitForEvents(t *testing.T, received <-chan testEvent, wanted map[string]int) []testEvent {
+	t.Helper()
+	var result []testEvent
+	found := make(map[string]int)
+	timeout := time.After(eventsWait)
+	for !hasEvents(found, wanted) {
+		select {
+		case event := <-received:
+			result = append(result, event)
+			found[event.Type]++
+		case <-timeout:
+			t.Fatalf("got events %v in %s, want %v", found, eventsWait, wanted)
+		}
+	}
+	return result
+}
+
+func hasEvents(found, wanted map[string]int) bool {
+	for eventType, count := range wanted {
+		if found[eventType] < count {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/web/model"
+	"net/http"
+	"strings"
+)
+
+// A RatingsAPIController binds http requests to an api service and writes the service results to the http response
+type RatingsAPIController struct {
+	service RatingsAPIServicer
+}
+
+// NewRatingsAPIController creates a default api controller
+func NewRatingsAPIController(s RatingsAPIServicer) Router {
+	return &RatingsAPIController{service: s}
+}
+
+// Routes returns all of the api route for the RatingsAPIController
+func (c *RatingsAPIController) Routes() Routes {
+	return Routes{
+		{
+			"RatingsGet",
+			strings.ToUpper("Get"),
+			"/ratings",
+			c.RatingsGet,
+		},
+	}
+}
+
+// RatingsGet -
+func (c *RatingsAPIController) RatingsGet(w http.ResponseWriter, r *http.Request) {
+	ratingsRequest := model.RatingsRequest{Game: r.URL.Query().Get("game")}
+	result, err := c.service.RatingsGet(ratingsRequest)
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusOK, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
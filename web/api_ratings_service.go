@@ -0,0 +1,50 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/core"
+	"botServer/web/model"
+	"math"
+	"time"
+)
+
+// RatingsAPIService is a service that implements the logic for the RatingsAPIServicer
+type RatingsAPIService struct {
+}
+
+// NewRatingsAPIService creates a default api service
+func NewRatingsAPIService() RatingsAPIServicer {
+	return &RatingsAPIService{}
+}
+
+// RatingsGet returns the leaderboard of the game
+func (s *RatingsAPIService) RatingsGet(request model.RatingsRequest) ([]model.Rating, error) {
+	ratings, err := core.Ratings(request.Game)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.Rating, 0, len(ratings))
+	for _, rating := range ratings {
+		result = append(result, model.Rating{
+			Rank:      rating.Rank,
+			BotID:     rating.BotID.String(),
+			BotName:   rating.BotName,
+			Rating:    int(math.Round(rating.Rating)),
+			Games:     rating.Games,
+			Wins:      rating.Wins,
+			Losses:    rating.Losses,
+			Draws:     rating.Draws,
+			UpdatedAt: rating.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return result, nil
+}
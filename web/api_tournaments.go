@@ -0,0 +1,169 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/web/model"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+)
+
+// A TournamentsAPIController binds http requests to an api service and writes the service results to the http response,
+// creating a tournament needs the admin token as a bearer token
+type TournamentsAPIController struct {
+	service      TournamentsAPIServicer
+	adminService AdminAPIServicer
+}
+
+// NewTournamentsAPIController creates a default api controller
+func NewTournamentsAPIController(s TournamentsAPIServicer, adminService AdminAPIServicer) Router {
+	return &TournamentsAPIController{service: s, adminService: adminService}
+}
+
+// Routes returns all of the api route for the TournamentsAPIController
+func (c *TournamentsAPIController) Routes() Routes {
+	return Routes{
+		{
+			"TournamentsPost",
+			strings.ToUpper("Post"),
+			"/tournaments",
+			c.TournamentsPost,
+		},
+		{
+			"TournamentGet",
+			strings.ToUpper("Get"),
+			"/tournaments/{tournamentId}",
+			c.TournamentGet,
+		},
+		{
+			"EntriesPost",
+			strings.ToUpper("Post"),
+			"/tournaments/{tournamentId}/entries",
+			c.EntriesPost,
+		},
+		{
+			"TournamentEventsGet",
+			strings.ToUpper("Get"),
+			"/tournaments/{tournamentId}/events",
+			c.TournamentEventsGet,
+		},
+	}
+}
+
+// TournamentsPost creates a tournament of registered bots
+func (c *TournamentsAPIController) TournamentsPost(w http.ResponseWriter, r *http.Request) {
+	if err := c.adminService.Authorize(bearerToken(r)); err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusUnauthorized, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+	createTournamentRequest := &model.CreateTournamentRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&createTournamentRequest); err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	result, err := c.service.TournamentsPost(*createTournamentRequest)
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusCreated, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
+
+// TournamentGet -
+func (c *TournamentsAPIController) TournamentGet(w http.ResponseWriter, r *http.Request) {
+	result, err := c.service.TournamentGet(mux.Vars(r)["tournamentId"])
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusOK, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
+
+// EntriesPost enters a bot in a tournament with its API key
+func (c *TournamentsAPIController) EntriesPost(w http.ResponseWriter, r *http.Request) {
+	entryRequest := &model.EntryRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&entryRequest); err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, http.StatusBadRequest, w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+	entryRequest.TournamentID = mux.Vars(r)["tournamentId"]
+
+	result, err := c.service.EntriesPost(*entryRequest)
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusCreated, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
+
+// TournamentEventsGet waits for the tournamentUpdate events of an entry, for bots without a callback
+func (c *TournamentsAPIController) TournamentEventsGet(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	eventsRequest := model.TournamentEventsRequest{
+		TournamentID: mux.Vars(r)["tournamentId"],
+		EntryID:      query.Get("entryId"),
+		Since:        query.Get("since"),
+		Token:        bearerToken(r),
+	}
+	result, err := c.service.TournamentEventsGet(r.Context(), eventsRequest)
+	if err != nil {
+		errorResponse := &model.Error{Message: err.Error()}
+		err = EncodeJSONResponse(errorResponse, errorStatus(err), w)
+		if err != nil {
+			handleServerError(w, err)
+		}
+		return
+	}
+
+	err = EncodeJSONResponse(result, http.StatusOK, w)
+	if err != nil {
+		handleServerError(w, err)
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ * Bot Server API
+ *
+ * This is a bot API to let bots battle
+ *
+ * API version: 1.0.0
+ * Contact: szederjesiarnold@gmail.com
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package web
+
+import (
+	"botServer/core"
+	"botServer/core/events"
+	"botServer/web/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// TournamentsAPIService is a service that implements the logic for the TournamentsAPIServicer
+type TournamentsAPIService struct {
+}
+
+// NewTournamentsAPIService creates a default api service
+func NewTournamentsAPIService() TournamentsAPIServicer {
+	return &TournamentsAPIService{}
+}
+
+// TournamentsPost creates a tournament, it starts once every bot entered it
+func (s *TournamentsAPIService) TournamentsPost(request model.CreateTournamentRequest) (model.Tournament, error) {
+	botIDs := make([]uuid.UUID, 0, len(request.Bots))
+	for _, bot := range request.Bots {
+		botID, err := uuid.Parse(bot)
+		if err != nil {
+			return model.Tournament{}, errors.Wrap(err, "could not create tournament: invalid bot id")
+		}
+		botIDs = append(botIDs, botID)
+	}
+	tournament, err := core.CreateTournament(core.TournamentRequest{
+		GameName:      request.Game.Name,
+		TotalRounds:   request.Game.TotalRounds,
+		MoveTimeout:   time.Duration(request.Game.MoveTimeout) * time.Second,
+		TimeoutPolicy: request.Game.TimeoutPolicy,
+		GameOptions:   request.Game.Options,
+		Format:        request.Format,
+		BotIDs:        botIDs,
+		Rounds:        request.Rounds,
+	})
+	if err != nil {
+		return model.Tournament{}, err
+	}
+	return toModelTournament(tournament), nil
+}
+
+// TournamentGet returns the tournament with its standings
+func (s *TournamentsAPIService) TournamentGet(tournamentID string) (model.Tournament, error) {
+	id, err := uuid.Parse(tournamentID)
+	if err != nil {
+		return model.Tournament{}, errors.Wrap(err, "could not get tournament: invalid tournament id")
+	}
+	tournament, err := core.GetTournament(id)
+	if err != nil {
+		return model.Tournament{}, err
+	}
+	return toModelTournament(tournament), nil
+}
+
+// EntriesPost enters a bot in the tournament
+func (s *TournamentsAPIService) EntriesPost(request model.EntryRequest) (model.Entry, error) {
+	tournamentID, err := uuid.Parse(request.TournamentID)
+	if err != nil {
+		return model.Entry{}, errors.Wrap(err, "could not enter tournament: invalid tournament id")
+	}
+	callbackURL, err := url.Parse(request.EventCallback)
+	if err != nil {
+		return model.Entry{}, err
+	}
+	entry, err := core.EnterTournament(core.EntryRequest{
+		TournamentID:  tournamentID,
+		APIKey:        request.APIKey,
+		EventCallback: callbackURL,
+		LegacyScore:   request.LegacyScore,
+		Polling:       request.Polling,
+	})
+	if err != nil {
+		return model.Entry{}, err
+	}
+	return model.Entry{
+		EntryID:       entry.ID.String(),
+		BotID:         entry.BotID.String(),
+		BotName:       entry.BotName,
+		Token:         entry.Token,
+		SigningSecret: entry.SigningSecret,
+	}, nil
+}
+
+// TournamentEventsGet returns the tournamentUpdate events of the entry after the since sequence number,
+// waiting for one if there are none yet
+func (s *TournamentsAPIService) TournamentEventsGet(ctx context.Context, request model.TournamentEventsRequest) ([]model.Event, error) {
+	tournamentID, err := uuid.Parse(request.TournamentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get tournament events: invalid tournament id")
+	}
+	entryID, err := uuid.Parse(request.EntryID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get tournament events: invalid entry id")
+	}
+	since := 0
+	if request.Since != "" {
+		since, err = strconv.Atoi(request.Since)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not get tournament events: invalid since")
+		}
+	}
+	result, err := core.TournamentEvents(ctx, tournamentID, entryID, request.Token, since, pollTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		result = []model.Event{}
+	}
+	return result, nil
+}
+
+func toModelTournament(tournament core.Tournament) model.Tournament {
+	return model.Tournament{
+		ID:        tournament.ID.String(),
+		Game:      tournament.GameName,
+		Format:    tournament.Format,
+		Status:    tournament.Status,
+		Round:     tournament.Round,
+		Rounds:    tournament.Rounds,
+		Standings: events.ToModelStandings(tournament.Standings),
+		Games:     events.ToModelTournamentGames(tournament.Games),
+		CreatedAt: tournament.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -1,10 +1,13 @@
 package web
 
 import (
+	"botServer/core"
 	"encoding/json"
 	"github.com/google/logger"
+	"github.com/pkg/errors"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // EncodeJSONResponse uses the json encoder to write an interface to the http response with an optional status code
@@ -20,7 +23,25 @@ func parseIntParameter(param string) (int64, error) {
 	return strconv.ParseInt(param, 10, 64)
 }
 
+// bearerToken returns the token of the Authorization header, empty if there is none
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+	return strings.TrimPrefix(header, "Bearer ")
+}
+
 func handleServerError(w http.ResponseWriter, err error) {
 	logger.Error(err)
 	w.WriteHeader(500)
 }
+
+// errorStatus returns 401 (Unauthorized) for an error caused by a wrong player token or API key,
+// 400 (Bad request) otherwise
+func errorStatus(err error) int {
+	if cause := errors.Cause(err); cause == core.ErrUnauthorized || cause == core.ErrInvalidAPIKey {
+		return http.StatusUnauthorized
+	}
+	return http.StatusBadRequest
+}
@@ -26,9 +26,22 @@ func Logger(inner http.Handler, name string) http.Handler {
 		logger.Infof(
 			"%s %s %s %s",
 			r.Method,
-			r.RequestURI,
+			redactedURI(r),
 			name,
 			time.Since(start),
 		)
 	})
 }
+
+// redactedURI is the URI of the request without the value of a token query parameter,
+// so that a client sending its secret in the URL does not leak it to the logs
+func redactedURI(r *http.Request) string {
+	query := r.URL.Query()
+	if _, ok := query["token"]; !ok {
+		return r.RequestURI
+	}
+	query.Set("token", "REDACTED")
+	redacted := *r.URL
+	redacted.RawQuery = query.Encode()
+	return redacted.RequestURI()
+}
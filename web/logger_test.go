@@ -0,0 +1,22 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedactedURIHidesTheToken(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "/events/stream?gameId=1&playerId=2", want: "/events/stream?gameId=1&playerId=2"},
+		{uri: "/ws?token=secret", want: "/ws?token=REDACTED"},
+		{uri: "/events/stream?playerId=2&token=secret&gameId=1", want: "/events/stream?gameId=1&playerId=2&token=REDACTED"},
+	}
+	for _, test := range tests {
+		if got := redactedURI(httptest.NewRequest("GET", test.uri, nil)); got != test.want {
+			t.Errorf("%s is logged as %s, want %s", test.uri, got, test.want)
+		}
+	}
+}
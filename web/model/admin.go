@@ -0,0 +1,30 @@
+package model
+
+// DeadLetter is an event that could not be delivered to an event callback
+type DeadLetter struct {
+	ID       string `json:"id"`
+	Callback string `json:"callback"`
+	Event    Event  `json:"event"`
+	Attempts int    `json:"attempts"`
+	// Error of the last attempt
+	Error    string `json:"error"`
+	FailedAt string `json:"failedAt"`
+}
+
+// Bot is a registered bot
+type Bot struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// CreateBotRequest is the input for registering a bot
+type CreateBotRequest struct {
+	Name string `json:"name"`
+}
+
+// BotAPIKey holds the API key of a bot, it is only shown when it is created
+type BotAPIKey struct {
+	Bot    Bot    `json:"bot"`
+	APIKey string `json:"apiKey"`
+}
@@ -2,10 +2,38 @@ package model
 
 // Event represents data that is notified to the clients
 type Event struct {
+	// Seq numbers the events of a game, starting from 1, a player only gets the events sent to it,
+	// so the numbers it sees can have gaps
+	Seq  int         `json:"seq,omitempty"`
 	Type string      `json:"type"`
 	Body interface{} `json:"body"`
 }
 
+// EventsRequest holds the query of a long-poll for the events of a player
+type EventsRequest struct {
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"playerId"`
+	// Sequence number of the last event received, only the events after it are returned
+	Since string `json:"since,omitempty"`
+	Token string `json:"-"`
+}
+
+// EventsStreamRequest holds the query of a stream of server-sent events for a player
+type EventsStreamRequest struct {
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"playerId"`
+	// Sequence number of the last event received before reconnecting, the events after it are sent again
+	LastEventID string `json:"lastEventId,omitempty"`
+	Token       string `json:"-"`
+}
+
+// SpectateRequest holds the query of a stream of server-sent events for a spectator of a game
+type SpectateRequest struct {
+	GameID string `json:"gameId"`
+	// Sequence number of the last event received before reconnecting, the events after it are sent again
+	LastEventID string `json:"lastEventId,omitempty"`
+}
+
 // StartGame is the event which signals clients that the game can start
 type StartGame struct {
 	GameID    string   `json:"gameId,omitempty"`
@@ -13,6 +41,12 @@ type StartGame struct {
 	NextRound int      `json:"nextRound,omitempty"`
 }
 
+// YourTurn is the event which tells a client that it has to make a move in the given round
+type YourTurn struct {
+	GameID string `json:"gameId"`
+	Round  int    `json:"round"`
+}
+
 // RoundFinished is the event which tells clients that the round is finished,
 // and sends them the results
 type RoundFinished struct {
@@ -20,21 +54,64 @@ type RoundFinished struct {
 	CurrentRound int    `json:"currentRound"`
 	RoundResult  Result `json:"roundResult"`
 	NextRound    int    `json:"nextRound"`
-	// Score after the current round
-	Score string `json:"score"`
+	// Scores of all the players after the current round, from the first place to the last
+	Scores []Score `json:"scores"`
+	// Deprecated: dash separated score, only sent to players who asked for the legacy score
+	Score string `json:"score,omitempty"`
+	// Game specific state after the current round, like the board
+	GameState interface{} `json:"gameState,omitempty"`
 }
 
 // GameFinished is the event which tells clients that the game is finished,
 // and sends them the results
 type GameFinished struct {
-	GameID     string `json:"gameId"`
-	Score      string `json:"score"`
+	GameID string `json:"gameId"`
+	// Final scores of all the players, from the first place to the last
+	Scores []Score `json:"scores"`
+	// Deprecated: dash separated score, only sent to players who asked for the legacy score
+	Score      string `json:"score,omitempty"`
 	GameResult Result `json:"gameResult"`
+	// Game specific state at the end of the game, like the board
+	GameState interface{} `json:"gameState,omitempty"`
+}
+
+// RoundTimeout is the event which tells clients that some players did not make their move in time,
+// the round is resolved according to the policy of the game
+type RoundTimeout struct {
+	GameID          string   `json:"gameId"`
+	Round           int      `json:"round"`
+	Policy          string   `json:"policy"`
+	PlayersTimedOut []string `json:"playersTimedOut"`
+}
+
+// MatchFound is the event which tells a bot waiting in the matchmaking queue the game it was put in,
+// the bot plays it with the token and signing secret of its ticket
+type MatchFound struct {
+	TicketID string `json:"ticketId"`
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"playerId"`
+	Game     string `json:"game"`
+	// Names of the players of the game, in the order they were matched
+	Players []string `json:"players"`
+	Rounds  int      `json:"rounds"`
 }
 
 // Result holds the data that is the result of a round or a game
 type Result struct {
-	Status string          `json:"status"`
+	Status string `json:"status"`
+	// Names of the winners separated by comma
 	Winner string          `json:"winner,omitempty"`
 	Moves  map[string]Move `json:"moves,omitempty"`
+	// Points each player got in the round
+	Points map[string]int `json:"points,omitempty"`
+}
+
+// Score holds the total score of a player, players with the same score share the rank
+type Score struct {
+	PlayerName string `json:"playerName"`
+	PlayerID   string `json:"playerId"`
+	// Id of the registered bot, missing for anonymous players
+	BotID string `json:"botId,omitempty"`
+	Score int    `json:"score"`
+	Rank  int    `json:"rank"`
 }
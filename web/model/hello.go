@@ -17,6 +17,12 @@ type HelloRequest struct {
 	PlayerName string `json:"playerName,omitempty"`
 	// To receive notifications from server
 	EventCallback string `json:"eventCallback,omitempty"`
+	// Also send the score as the deprecated dash separated string in roundFinished and gameFinished
+	LegacyScore bool `json:"legacyScore,omitempty"`
+	// The player fetches its events from GET /events instead of having them pushed
+	Polling bool `json:"polling,omitempty"`
+	// API key of a registered bot, the player gets the name of the bot
+	APIKey string `json:"apiKey,omitempty"`
 }
 
 // HelloRequestGame describes the game in the context of the hello request
@@ -26,6 +32,12 @@ type HelloRequestGame struct {
 	ConnectionToken      string `json:"connectionToken"`
 	NumberOfTotalPlayers int    `json:"numberOfTotalPlayers,omitempty"`
 	TotalRounds          int    `json:"totalRounds,omitempty"`
+	// Seconds a player has to make a move in a round, no limit if not provided
+	MoveTimeout int `json:"moveTimeout,omitempty"`
+	// What happens when a player does not move in time: forfeit (default), random or end
+	TimeoutPolicy string `json:"timeoutPolicy,omitempty"`
+	// Game specific settings, like the payoff matrix of prisoners_dilemma
+	Options map[string]interface{} `json:"options,omitempty"`
 }
 
 // HelloResponse is the HTTP response from the hello endpoint
@@ -34,15 +46,32 @@ type HelloResponse struct {
 	Player HelloResponsePlayer `json:"player,omitempty"`
 	// Number of rounds to play
 	Rounds int `json:"rounds,omitempty"`
+	// Key of the HMAC-SHA256 signature of the events posted to the event callback
+	SigningSecret string `json:"signingSecret,omitempty"`
+	// Bearer token the player authenticates with on /play, /ws and /events
+	Token string `json:"token,omitempty"`
 }
 
 // HelloResponsePlayer describes a player in the context of a hello response
 type HelloResponsePlayer struct {
 	ID   string `json:"id,omitempty"`
 	Name string `json:"name,omitempty"`
+	// Id of the registered bot, the same in every game, missing for anonymous players
+	BotID string `json:"botId,omitempty"`
 }
 
 type SwitchToWsRequest struct {
 	GameID   string `json:"gameId"`
 	PlayerID string `json:"playerId"`
+	// Sequence number of the last event received before reconnecting, the events after it are sent again
+	LastSeq string `json:"lastSeq,omitempty"`
+	// Token of the player, sent as a bearer token or as the token query parameter
+	Token string `json:"-"`
+}
+
+// LeaveRequest identifies the player who leaves a game
+type LeaveRequest struct {
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"playerId"`
+	Token    string `json:"-"`
 }
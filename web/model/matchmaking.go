@@ -0,0 +1,48 @@
+package model
+
+// QueueRequest is the HTTP request body for joining the matchmaking queue
+type QueueRequest struct {
+	Game QueueRequestGame `json:"game"`
+	// If name is not provided, server will assign a name
+	PlayerName string `json:"playerName,omitempty"`
+	// To receive the matchFound event and the events of the game
+	EventCallback string `json:"eventCallback,omitempty"`
+	// Also send the score as the deprecated dash separated string in roundFinished and gameFinished
+	LegacyScore bool `json:"legacyScore,omitempty"`
+	// The player fetches the events of the game from GET /events instead of having them pushed
+	Polling bool `json:"polling,omitempty"`
+	// API key of a registered bot, the player gets the name of the bot
+	APIKey string `json:"apiKey,omitempty"`
+}
+
+// QueueRequestGame describes the game a bot waits for
+type QueueRequestGame struct {
+	Name                 string `json:"name"`
+	NumberOfTotalPlayers int    `json:"numberOfTotalPlayers,omitempty"`
+}
+
+// Ticket is the place of a bot in the matchmaking queue
+type Ticket struct {
+	TicketID             string `json:"ticketId"`
+	Game                 string `json:"game"`
+	NumberOfTotalPlayers int    `json:"numberOfTotalPlayers"`
+	// One of waiting, matched, cancelled or failed
+	Status string `json:"status"`
+	// Rating of the bot in the game when it joined the queue, bots are matched with bots of a close rating
+	Rating int `json:"rating"`
+	// Bearer token of the ticket, the bot keeps it as its player token in the matched game
+	Token string `json:"token,omitempty"`
+	// Key of the HMAC-SHA256 signature of the events posted to the event callback, kept in the matched game
+	SigningSecret string `json:"signingSecret,omitempty"`
+	// The game the ticket was put in, once it is matched
+	Match *MatchFound `json:"match,omitempty"`
+	// Why the bot could not join the game it was matched to
+	Error      string `json:"error,omitempty"`
+	EnqueuedAt string `json:"enqueuedAt"`
+}
+
+// TicketRequest identifies a ticket of the matchmaking queue
+type TicketRequest struct {
+	TicketID string `json:"ticketId"`
+	Token    string `json:"-"`
+}
@@ -16,6 +16,8 @@ type PlayRequest struct {
 	PlayerID string `json:"playerId"`
 	Round    int    `json:"round"`
 	Move     Move   `json:"move"`
+	// Token of the player, sent as a bearer token
+	Token string `json:"-"`
 }
 
 // Move is game specific, and represents a player's move/actions
@@ -0,0 +1,21 @@
+package model
+
+// Rating is the place of a bot on the leaderboard of a game
+type Rating struct {
+	// Bots with the same rating share the rank
+	Rank    int    `json:"rank"`
+	BotID   string `json:"botId"`
+	BotName string `json:"botName"`
+	// Elo rating, bots start from 1500
+	Rating    int    `json:"rating"`
+	Games     int    `json:"games"`
+	Wins      int    `json:"wins"`
+	Losses    int    `json:"losses"`
+	Draws     int    `json:"draws"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+// RatingsRequest holds the query of a leaderboard
+type RatingsRequest struct {
+	Game string `json:"game"`
+}
@@ -0,0 +1,119 @@
+package model
+
+// CreateTournamentRequest is the HTTP request body for creating a tournament
+type CreateTournamentRequest struct {
+	Game TournamentRequestGame `json:"game"`
+	// One of round-robin, swiss, single-elimination or double-elimination
+	Format string `json:"format"`
+	// Ids of the registered bots playing, in the order of their seeds
+	Bots []string `json:"bots"`
+	// Number of rounds of a Swiss tournament, enough to find a winner if not provided
+	Rounds int `json:"rounds,omitempty"`
+}
+
+// TournamentRequestGame describes the games played in a tournament
+type TournamentRequestGame struct {
+	Name        string `json:"name"`
+	TotalRounds int    `json:"totalRounds,omitempty"`
+	// Seconds a player has to make a move in a round, no limit if not provided
+	MoveTimeout int `json:"moveTimeout,omitempty"`
+	// What happens when a player does not move in time: forfeit (default), random or end
+	TimeoutPolicy string `json:"timeoutPolicy,omitempty"`
+	// Game specific settings, like the payoff matrix of prisoners_dilemma
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// Tournament is a competition of registered bots
+type Tournament struct {
+	ID     string `json:"id"`
+	Game   string `json:"game"`
+	Format string `json:"format"`
+	// One of registering, running or finished
+	Status string `json:"status"`
+	// The round being played, 0 while bots are entering
+	Round int `json:"round"`
+	// Number of rounds, missing for elimination tournaments, which end when one bot is left
+	Rounds    int              `json:"rounds,omitempty"`
+	Standings []Standing       `json:"standings"`
+	Games     []TournamentGame `json:"games"`
+	CreatedAt string           `json:"createdAt"`
+}
+
+// Standing is the place of a bot in a tournament
+type Standing struct {
+	// Bots with the same points share the rank, except in elimination tournaments
+	Rank    int     `json:"rank"`
+	BotID   string  `json:"botId"`
+	BotName string  `json:"botName"`
+	Points  float64 `json:"points"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	Draws   int     `json:"draws"`
+	Byes    int     `json:"byes"`
+	// The bot is out of an elimination tournament
+	Eliminated bool `json:"eliminated,omitempty"`
+}
+
+// TournamentGame is a game of a tournament
+type TournamentGame struct {
+	Round int `json:"round"`
+	// Missing when the game could not be created
+	GameID  string   `json:"gameId,omitempty"`
+	Players []string `json:"players"`
+	// One of playing, finished or forfeited
+	Status string `json:"status"`
+	// Missing while the game is played and for a draw
+	Winner string `json:"winner,omitempty"`
+}
+
+// TournamentUpdate is the event which tells the bots of a tournament the standings, and the game they play next
+type TournamentUpdate struct {
+	TournamentID string `json:"tournamentId"`
+	Status       string `json:"status"`
+	Round        int    `json:"round"`
+	// From the first place to the last, the final standings once the tournament is finished
+	Standings []Standing `json:"standings"`
+	// Games of the current round
+	Games []TournamentGame `json:"games"`
+	// The game the bot plays in the current round, it plays it with the token of its entry
+	Game *PlayerGame `json:"game,omitempty"`
+}
+
+// PlayerGame identifies a player of a game
+type PlayerGame struct {
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"playerId"`
+}
+
+// EntryRequest is the HTTP request body for entering a tournament
+type EntryRequest struct {
+	TournamentID string `json:"-"`
+	// API key of a bot of the tournament
+	APIKey string `json:"apiKey"`
+	// To receive the tournamentUpdate events and the events of the games
+	EventCallback string `json:"eventCallback,omitempty"`
+	// Also send the score as the deprecated dash separated string in roundFinished and gameFinished
+	LegacyScore bool `json:"legacyScore,omitempty"`
+	// The bot fetches the events of its games from GET /events instead of having them pushed
+	Polling bool `json:"polling,omitempty"`
+}
+
+// Entry is a bot that entered a tournament
+type Entry struct {
+	EntryID string `json:"entryId"`
+	BotID   string `json:"botId"`
+	BotName string `json:"botName"`
+	// Bearer token of the entry, the bot is the player of its games with it
+	Token string `json:"token"`
+	// Key of the HMAC-SHA256 signature of the events posted to the event callback
+	SigningSecret string `json:"signingSecret"`
+}
+
+// TournamentEventsRequest holds the query of a long-poll for the tournamentUpdate events of an entry
+type TournamentEventsRequest struct {
+	TournamentID string `json:"tournamentId"`
+	EntryID      string `json:"entryId"`
+	// Sequence number of the last event received, only the events after it are returned
+	Since string `json:"since,omitempty"`
+	Token string `json:"-"`
+}
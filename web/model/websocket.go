@@ -0,0 +1,27 @@
+package model
+
+import "encoding/json"
+
+// WebsocketMessage is a message sent by a client over its websocket connection,
+// the reply to it carries the same request id
+type WebsocketMessage struct {
+	// One of hello, play, ping or leave
+	Type      string          `json:"type"`
+	RequestID string          `json:"requestId,omitempty"`
+	Body      json.RawMessage `json:"body,omitempty"`
+}
+
+// WebsocketPlay is the body of the play message, the game and the player are the ones of the connection
+type WebsocketPlay struct {
+	Round int  `json:"round"`
+	Move  Move `json:"move"`
+}
+
+// WebsocketReply is sent by the server as the answer to a websocket message
+type WebsocketReply struct {
+	// One of ack, pong or rejected, rejected is distinct from the error event sent over the same connection
+	Type      string `json:"type"`
+	RequestID string `json:"requestId,omitempty"`
+	// HelloResponse for an acknowledged hello, PlayResponse for an acknowledged play, Error for a rejected message
+	Body interface{} `json:"body,omitempty"`
+}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"botServer/web/model"
+	"github.com/gorilla/websocket"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const writeWait = 10 * time.Second
+
+// WebsocketConn wraps a websocket connection, so that events and replies
+// can be written to it from multiple goroutines, it is the transport of the events of a websocket player
+type WebsocketConn struct {
+	conn      *websocket.Conn
+	writeLock sync.Mutex
+	pongWait  time.Duration
+	closed    int32
+}
+
+func newWebsocketConn(conn *websocket.Conn) *WebsocketConn {
+	return &WebsocketConn{conn: conn}
+}
+
+// Send writes the event as a JSON message
+func (c *WebsocketConn) Send(event model.Event) error {
+	return c.WriteJSON(event)
+}
+
+// Healthy tells whether the connection is still open
+func (c *WebsocketConn) Healthy() bool {
+	return atomic.LoadInt32(&c.closed) == 0
+}
+
+// WriteJSON writes the given value as a JSON message
+func (c *WebsocketConn) WriteJSON(v interface{}) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+// ReadMessage reads the next message, only one goroutine may read at a time
+func (c *WebsocketConn) ReadMessage() ([]byte, error) {
+	_, message, err := c.conn.ReadMessage()
+	if err == nil && c.pongWait > 0 {
+		err = c.conn.SetReadDeadline(time.Now().Add(c.pongWait))
+	}
+	return message, err
+}
+
+// KeepAlive pings the other side periodically, reading fails when nothing,
+// not even a pong, was received for pongWait, it has to be called before reading
+func (c *WebsocketConn) KeepAlive(pongWait time.Duration) (stop func()) {
+	c.pongWait = pongWait
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(pongWait * 9 / 10)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// Close sends a close message with the given reason, and closes the connection
+func (c *WebsocketConn) Close(reason string) error {
+	atomic.StoreInt32(&c.closed, 1)
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	cm := websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason)
+	_ = c.conn.WriteMessage(websocket.CloseMessage, cm)
+	return c.conn.Close()
+}
@@ -0,0 +1,119 @@
+package web
+
+import (
+	"botServer/web/model"
+	"encoding/json"
+	"github.com/google/logger"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// pongWait is how long a websocket connection may stay silent before the player is considered disconnected
+const pongWait = 60 * time.Second
+
+// websocketSession serves the messages a player sends over its websocket connection,
+// every message is answered with a reply carrying the same request id,
+// the game and player ids are empty until the player joins a game with a hello message
+type websocketSession struct {
+	conn           *WebsocketConn
+	gameID         string
+	playerID       string
+	token          string
+	connectService ConnectAPIServicer
+	playService    PlayAPIServicer
+}
+
+// serve reads messages until the connection is closed or goes silent, then tells the game the player is gone
+func (s *websocketSession) serve() {
+	stopKeepAlive := s.conn.KeepAlive(pongWait)
+	defer stopKeepAlive()
+	for {
+		data, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger.Warning(errors.Wrapf(err, "websocket of player %s closed", s.playerID))
+			}
+			s.disconnect()
+			return
+		}
+		var message model.WebsocketMessage
+		var reply model.WebsocketReply
+		if err := json.Unmarshal(data, &message); err != nil {
+			reply = rejectedReply("", errors.Wrap(err, "invalid message"))
+		} else {
+			reply = s.handle(message)
+		}
+		if err := s.conn.WriteJSON(reply); err != nil {
+			logger.Error(errors.Wrap(err, "could not reply through websocket"))
+			s.disconnect()
+			return
+		}
+	}
+}
+
+func (s *websocketSession) handle(message model.WebsocketMessage) model.WebsocketReply {
+	if message.Type != "hello" && message.Type != "ping" && s.playerID == "" {
+		return rejectedReply(message.RequestID, errors.New("join a game with a hello message first"))
+	}
+	switch message.Type {
+	case "hello":
+		if s.playerID != "" {
+			return rejectedReply(message.RequestID, errors.New("already joined a game"))
+		}
+		var hello model.HelloRequest
+		if err := json.Unmarshal(message.Body, &hello); err != nil {
+			return rejectedReply(message.RequestID, errors.Wrap(err, "invalid hello message"))
+		}
+		helloResponse, err := s.connectService.HelloWs(hello, s.conn)
+		if err != nil {
+			return rejectedReply(message.RequestID, err)
+		}
+		s.gameID = helloResponse.GameID
+		s.playerID = helloResponse.Player.ID
+		s.token = helloResponse.Token
+		return model.WebsocketReply{Type: "ack", RequestID: message.RequestID, Body: helloResponse}
+	case "play":
+		var play model.WebsocketPlay
+		if err := json.Unmarshal(message.Body, &play); err != nil {
+			return rejectedReply(message.RequestID, errors.Wrap(err, "invalid play message"))
+		}
+		playResponse, err := s.playService.PlayPost(model.PlayRequest{
+			GameID:   s.gameID,
+			PlayerID: s.playerID,
+			Token:    s.token,
+			Round:    play.Round,
+			Move:     play.Move,
+		})
+		if err != nil {
+			logger.Warning(err.Error())
+			return rejectedReply(message.RequestID, err)
+		}
+		return model.WebsocketReply{Type: "ack", RequestID: message.RequestID, Body: playResponse}
+	case "ping":
+		return model.WebsocketReply{Type: "pong", RequestID: message.RequestID}
+	case "leave":
+		err := s.connectService.Leave(model.LeaveRequest{GameID: s.gameID, PlayerID: s.playerID, Token: s.token})
+		if err != nil {
+			return rejectedReply(message.RequestID, err)
+		}
+		return model.WebsocketReply{Type: "ack", RequestID: message.RequestID}
+	default:
+		return rejectedReply(message.RequestID, errors.Errorf("unknown message type %q, expecting hello, play, ping or leave", message.Type))
+	}
+}
+
+func (s *websocketSession) disconnect() {
+	_ = s.conn.Close("connection lost")
+	if s.playerID == "" {
+		return
+	}
+	request := model.SwitchToWsRequest{GameID: s.gameID, PlayerID: s.playerID}
+	if err := s.connectService.DisconnectWs(request, s.conn); err != nil {
+		logger.Infof("Websocket of player %s closed after leaving the game: %v", s.playerID, err)
+	}
+}
+
+func rejectedReply(requestID string, err error) model.WebsocketReply {
+	return model.WebsocketReply{Type: "rejected", RequestID: requestID, Body: model.Error{Message: err.Error()}}
+}
@@ -0,0 +1,31 @@
+package web
+
+import (
+	"botServer/web/model"
+	"github.com/gorilla/websocket"
+	"strings"
+	"testing"
+)
+
+// TestWebsocketRejectsMessagesBeforeHello checks that a message the server cannot process is answered
+// with a rejected reply, which bots can tell apart from the error event
+func TestWebsocketRejectsMessagesBeforeHello(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteJSON(model.WebsocketMessage{Type: "play", RequestID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	var reply model.WebsocketReply
+	if err := conn.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Type != "rejected" || reply.RequestID != "1" {
+		t.Errorf("got reply %q to request %q, want %q to request %q", reply.Type, reply.RequestID, "rejected", "1")
+	}
+}